@@ -84,8 +84,8 @@ K8S_AI_BENCH_BIN="${BINDIR}/k8s-ai-bench"
 # Go back to REPO_ROOT to start running evals
 cd "${REPO_ROOT}"
 
-# Start evaluation loop
-echo "Starting evaluation loop..."
+# Start evaluation
+echo "Starting evaluation..."
 echo "Runs:      $ITERATIONS"
 echo "Provider:  $PROVIDER"
 echo "Model:     $MODEL"
@@ -93,75 +93,69 @@ echo "API Base:  $API_BASE"
 echo "Concurrency: $CONCURRENCY"
 echo "Task Pattern: ${TASK_PATTERN:-"All Tasks"}"
 
-# Loop from 1 to the specified number of iterations
-for i in $(seq 1 $ITERATIONS)
-do
-  # Create a sanitized version of model name: replace all '/' with '-'
-  SAFE_MODEL="${MODEL//\//-}"
-  OUTPUT_DIR="${REPO_ROOT}/.build/k8s-ai-bench-${SAFE_MODEL}-${i}"
-  
-  echo "Running iteration $i of $ITERATIONS..."
-
-  K8S_AI_BENCH_ARGS="--agent-bin kubectl-ai --kubeconfig ${KUBECONFIG:-~/.kube/config} --enable-tool-use-shim=false --llm-provider=${PROVIDER} --models=${MODEL} --quiet --output-dir=${OUTPUT_DIR} --cluster-creation-policy=${CLUSTER_CREATION_POLICY} --concurrency ${CONCURRENCY} --tasks-dir=${REPO_ROOT}/k8s-ai-bench/tasks "
-
-  if [ -n "$TASK_PATTERN" ]; then
-    K8S_AI_BENCH_ARGS+="--task-pattern=${TASK_PATTERN} "
-    echo "Applying task pattern: ${TASK_PATTERN}"
-  fi
-
-  # Execute the k8s-ai-bench command and capture the evaluation time line
-  run_time_line=$( \
-    OPENAI_API_KEY="not needed" \
-    OPENAI_API_BASE="$API_BASE" \
-    "${K8S_AI_BENCH_BIN}" run ${K8S_AI_BENCH_ARGS} | tee /dev/tty | grep '^Total evaluation time:' \
-  )
-
-  if [ ${PIPESTATUS[0]} -ne 0 ]; then
-    echo "Error on iteration $i during 'k8s-ai-bench run'. Aborting loop."
-    exit 1
-  fi
-
-  echo "Analyzing results for iteration $i..."
-  
-  # Paths for analysis files
-  MARKDOWN_FILE="${OUTPUT_DIR}/k8s-ai-bench.md"
-  JSON_FILE="${OUTPUT_DIR}/k8s-ai-bench.json"
-  JSONL_FILE="${OUTPUT_DIR}/k8s-ai-bench.jsonl"
-
-  # Run for markdown format
-  "${K8S_AI_BENCH_BIN}" analyze --input-dir="${OUTPUT_DIR}" --results-filepath="${MARKDOWN_FILE}" --output-format=markdown --show-failures
-  if [ $? -ne 0 ]; then
-    echo "Error on iteration $i during Markdown analysis. Aborting loop."
-    exit 1
-  fi
-
-  # Run for json format
-  "${K8S_AI_BENCH_BIN}" analyze --input-dir="${OUTPUT_DIR}" --results-filepath="${JSON_FILE}" --output-format=json --show-failures
-  if [ $? -ne 0 ]; then
-    echo "Error on iteration $i during JSON analysis. Aborting loop."
-    exit 1
-  fi
-
-  # Run for jsonl format
-  "${K8S_AI_BENCH_BIN}" analyze --input-dir="${OUTPUT_DIR}" --results-filepath="${JSONL_FILE}" --output-format=jsonl --show-failures
-  if [ $? -ne 0 ]; then
-    echo "Error on iteration $i during JSONL analysis. Aborting loop."
-    exit 1
-  fi
-
-  # Extract the time value and append it to the markdown file
-  if [ -n "$run_time_line" ]; then
-    time_value=$(echo $run_time_line | awk '{print $4}')
-    
-    # Append the time to the markdown file with some formatting
-    echo "" >> "${MARKDOWN_FILE}"
-    echo "---" >> "${MARKDOWN_FILE}"
-    echo "**Total evaluation time:** ${time_value}" >> "${MARKDOWN_FILE}"
-  else
-    echo "Warning: Could not find evaluation time for iteration $i."
-  fi
-
-  echo "Finished iteration $i."
-done
-
-echo "All $ITERATIONS runs completed successfully!"
\ No newline at end of file
+# Create a sanitized version of model name: replace all '/' with '-'
+SAFE_MODEL="${MODEL//\//-}"
+OUTPUT_DIR="${REPO_ROOT}/.build/k8s-ai-bench-${SAFE_MODEL}"
+
+echo "Running $ITERATIONS iterations..."
+
+K8S_AI_BENCH_ARGS="--agent-bin kubectl-ai --kubeconfig ${KUBECONFIG:-~/.kube/config} --enable-tool-use-shim=false --llm-provider=${PROVIDER} --models=${MODEL} --quiet --output-dir=${OUTPUT_DIR} --cluster-creation-policy=${CLUSTER_CREATION_POLICY} --concurrency ${CONCURRENCY} --tasks-dir=${REPO_ROOT}/k8s-ai-bench/tasks --iterations ${ITERATIONS} "
+
+if [ -n "$TASK_PATTERN" ]; then
+  K8S_AI_BENCH_ARGS+="--task-pattern=${TASK_PATTERN} "
+  echo "Applying task pattern: ${TASK_PATTERN}"
+fi
+
+# Execute the k8s-ai-bench command and capture the evaluation time line
+run_time_line=$( \
+  OPENAI_API_KEY="not needed" \
+  OPENAI_API_BASE="$API_BASE" \
+  "${K8S_AI_BENCH_BIN}" run ${K8S_AI_BENCH_ARGS} | tee /dev/tty | grep '^Total evaluation time:' \
+)
+
+if [ ${PIPESTATUS[0]} -ne 0 ]; then
+  echo "Error during 'k8s-ai-bench run'. Aborting."
+  exit 1
+fi
+
+echo "Analyzing results..."
+
+# Paths for analysis files
+MARKDOWN_FILE="${OUTPUT_DIR}/k8s-ai-bench.md"
+JSON_FILE="${OUTPUT_DIR}/k8s-ai-bench.json"
+JSONL_FILE="${OUTPUT_DIR}/k8s-ai-bench.jsonl"
+
+# Run for markdown format; it includes pass@k over the iterations
+"${K8S_AI_BENCH_BIN}" analyze --input-dir="${OUTPUT_DIR}" --results-filepath="${MARKDOWN_FILE}" --output-format=markdown --show-failures
+if [ $? -ne 0 ]; then
+  echo "Error during Markdown analysis. Aborting."
+  exit 1
+fi
+
+# Run for json format
+"${K8S_AI_BENCH_BIN}" analyze --input-dir="${OUTPUT_DIR}" --results-filepath="${JSON_FILE}" --output-format=json --show-failures
+if [ $? -ne 0 ]; then
+  echo "Error during JSON analysis. Aborting."
+  exit 1
+fi
+
+# Run for jsonl format
+"${K8S_AI_BENCH_BIN}" analyze --input-dir="${OUTPUT_DIR}" --results-filepath="${JSONL_FILE}" --output-format=jsonl --show-failures
+if [ $? -ne 0 ]; then
+  echo "Error during JSONL analysis. Aborting."
+  exit 1
+fi
+
+# Extract the time value and append it to the markdown file
+if [ -n "$run_time_line" ]; then
+  time_value=$(echo $run_time_line | awk '{print $4}')
+
+  # Append the time to the markdown file with some formatting
+  echo "" >> "${MARKDOWN_FILE}"
+  echo "---" >> "${MARKDOWN_FILE}"
+  echo "**Total evaluation time:** ${time_value}" >> "${MARKDOWN_FILE}"
+else
+  echo "Warning: Could not find evaluation time."
+fi
+
+echo "All $ITERATIONS iterations completed successfully!"
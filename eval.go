@@ -26,19 +26,30 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/gke-labs/k8s-ai-bench/pkg/agent"
 	"github.com/gke-labs/k8s-ai-bench/pkg/cluster"
 	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/kind"
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/pool"
 	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/vcluster"
+	"github.com/gke-labs/k8s-ai-bench/pkg/judge"
+	"github.com/gke-labs/k8s-ai-bench/pkg/llm"
 	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+	"github.com/gke-labs/k8s-ai-bench/pkg/trace"
+	"github.com/gke-labs/k8s-ai-bench/pkg/usersim"
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
 	"k8s.io/klog/v2"
 	"sigs.k8s.io/yaml"
 )
 
-func runEvaluation(ctx context.Context, config EvalConfig) error {
+// taskEvaluator runs one task for one LLM configuration; see evaluateTask and selfTestTask.
+type taskEvaluator func(ctx context.Context, config EvalConfig, taskID string, task Task, llmConfig model.LLMConfig, clusterProvider cluster.Provider, log io.Writer) model.TaskResult
+
+func runEvaluation(ctx context.Context, config EvalConfig, evaluate taskEvaluator) ([]model.TaskResult, error) {
 	logger := klog.FromContext(ctx)
 
 	var clusterProvider cluster.Provider
@@ -48,7 +59,7 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 	case "vcluster":
 		clusterProvider = vcluster.New(config.HostClusterContext, config.HostClusterKubeConfig, config.HostClusterIngressExternalIP)
 	default:
-		return fmt.Errorf("unknown cluster provider: %s", config.ClusterProvider)
+		return nil, fmt.Errorf("unknown cluster provider: %s", config.ClusterProvider)
 	}
 
 	if config.ClusterCreationPolicy != DoNotCreate {
@@ -56,13 +67,13 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 
 		clusterExists, err := clusterProvider.Exists(clusterName)
 		if err != nil {
-			return fmt.Errorf("failed to check if cluster exists: %w", err)
+			return nil, fmt.Errorf("failed to check if cluster exists: %w", err)
 		}
 
 		if config.ClusterCreationPolicy == AlwaysCreate && clusterExists {
 			logger.Info("Deleting existing cluster for evaluation run", "name", clusterName, "provider", config.ClusterProvider)
 			if err := clusterProvider.Delete(clusterName); err != nil {
-				return fmt.Errorf("failed to delete existing cluster: %w", err)
+				return nil, fmt.Errorf("failed to delete existing cluster: %w", err)
 			}
 			clusterExists = false
 		}
@@ -70,7 +81,7 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 		if !clusterExists {
 			logger.Info("Creating cluster for evaluation run", "name", clusterName, "provider", config.ClusterProvider)
 			if err := clusterProvider.Create(clusterName); err != nil {
-				return fmt.Errorf("failed to create cluster: %w", err)
+				return nil, fmt.Errorf("failed to create cluster: %w", err)
 			}
 		}
 
@@ -78,18 +89,18 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 		logger.Info("Getting kubeconfig for cluster", "name", clusterName)
 		kubeconfigBytes, err := clusterProvider.GetKubeconfig(clusterName)
 		if err != nil {
-			return fmt.Errorf("failed to get kubeconfig for cluster: %w", err)
+			return nil, fmt.Errorf("failed to get kubeconfig for cluster: %w", err)
 		}
 
 		// Write kubeconfig to a temp file
 		kubeconfigFile, err := os.CreateTemp("", "kubeconfig-*.yaml")
 		if err != nil {
-			return fmt.Errorf("failed to create temp file for kubeconfig: %w", err)
+			return nil, fmt.Errorf("failed to create temp file for kubeconfig: %w", err)
 		}
 		defer os.Remove(kubeconfigFile.Name()) // Clean up the temp file
 
 		if _, err := kubeconfigFile.Write(kubeconfigBytes); err != nil {
-			return fmt.Errorf("failed to write kubeconfig to temp file: %w", err)
+			return nil, fmt.Errorf("failed to write kubeconfig to temp file: %w", err)
 		}
 		kubeconfigFile.Close()
 
@@ -98,12 +109,37 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 	}
 
 	if config.OutputDir == "" {
-		return fmt.Errorf("must set OutputDir")
+		return nil, fmt.Errorf("must set OutputDir")
 	}
 
 	tasks, err := loadTasks(config)
 	if err != nil {
-		return fmt.Errorf("failed to load tasks: %w", err)
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	manifest, err := newRunManifest(ctx, config, tasks)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeRunManifest(config.OutputDir, manifest); err != nil {
+		return nil, err
+	}
+	fmt.Printf("Run ID: %s\n", manifest.RunID)
+
+	// Keep clusters warm for the tasks that need one of their own
+	if config.ClusterPoolSize > 0 && needsIsolatedClusters(config, tasks) {
+		clusterPool, err := pool.New(clusterProvider, pool.Config{
+			Size:       config.ClusterPoolSize,
+			Reset:      config.ClusterPoolReset,
+			NamePrefix: "k8s-ai-bench-pool",
+		})
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Warming a pool of %d clusters\n", config.ClusterPoolSize)
+		clusterPool.Warm(ctx)
+		config.clusterPool = clusterPool
+		defer closeClusterPool(ctx, clusterPool)
 	}
 
 	// Fallback to sequential execution if concurrency is not set
@@ -111,29 +147,111 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 		config.Concurrency = 1
 	}
 
-	// Create a channel for tasks to be processed
-	type taskJob struct {
-		taskID string
-		task   Task
+	// Every run of a task (prompt variant and iteration) for each LLM config is scheduled separately
+	iterations := max(config.Iterations, 1)
+	var units []workUnit
+	for iteration := 1; iteration <= iterations; iteration++ {
+		for taskID, task := range tasks {
+			// Each selected prompt variant is run as a separate unit
+			variants, err := task.selectVariants(config.PromptVariants, config.Seed, taskID)
+			if err != nil {
+				return nil, err
+			}
+			for _, variant := range variants {
+				variantTask, err := task.WithVariant(variant)
+				if err != nil {
+					return nil, fmt.Errorf("task %s: %w", taskID, err)
+				}
+				variantTask.iteration = iteration
+				for _, llmConfig := range config.LLMConfigs {
+					units = append(units, workUnit{taskID: taskID, task: variantTask, llmConfig: llmConfig})
+				}
+			}
+		}
 	}
-	taskCh := make(chan taskJob, len(tasks))
+
+	// With --resume and --rerun, only run the units that are missing or in the selected states.
+	attempts := make(map[runUnit]int)
+	if config.Resume || len(config.Rerun) > 0 {
+		existing, err := loadExistingResults(config.OutputDir)
+		if err != nil {
+			return nil, fmt.Errorf("reading existing results: %w", err)
+		}
+		var planned []workUnit
+		skipped := 0
+		for _, u := range units {
+			unit := unitOf(config.newResult(u.taskID, &u.task, u.llmConfig))
+			previous, found := existing[unit]
+			switch {
+			case !found && config.Resume:
+				// Missing results: run it.
+			case found && (slices.Contains(config.Rerun, previous.result.Result) ||
+				config.Resume && previous.result.Result == model.ResultCancelled):
+				// Keep the previous attempt, but out of the way of the new results.
+				n, err := archiveAttempt(previous.dir)
+				if err != nil {
+					return nil, fmt.Errorf("archiving results of %s: %w", unit, err)
+				}
+				attempts[unit] = n
+				fmt.Printf("Re-running %s (previous result: %s)\n", unit, previous.result.Result)
+			default:
+				skipped++
+				continue
+			}
+			planned = append(planned, u)
+		}
+		fmt.Printf("Skipping %d runs that already have results\n", skipped)
+		units = planned
+	}
+	queue := newScheduler(ctx, &config, units)
 
 	// Create a channel for collecting results
-	resultsCh := make(chan model.TaskResult, len(tasks)*len(config.LLMConfigs))
+	resultsCh := make(chan model.TaskResult, len(units))
 
 	// Create a separate channel for errors
 	errorsCh := make(chan error, config.Concurrency)
 
-	// Load all tasks into the tasks channel
-	for taskID, task := range tasks {
-		taskCh <- taskJob{taskID: taskID, task: task}
+	// evaluateUnit runs one unit and writes its results
+	evaluateUnit := func(workerID int, unit workUnit) error {
+		taskOutputDir := config.taskOutputDir(unit.taskID, &unit.task, unit.llmConfig)
+		if err := os.MkdirAll(taskOutputDir, 0755); err != nil {
+			return fmt.Errorf("creating directory %q: %w", taskOutputDir, err)
+		}
+
+		logPath := filepath.Join(taskOutputDir, "log.txt")
+		logFile, err := os.Create(logPath)
+		if err != nil {
+			return fmt.Errorf("creating log file %q: %w", logPath, err)
+		}
+		defer logFile.Close()
+
+		start := time.Now()
+		fmt.Printf("\033[36mWorker %d: Started %s for %s\033[0m\n", workerID, unit.llmConfig.ID, unit.taskID)
+
+		result := evaluate(ctx, config, unit.taskID, unit.task, unit.llmConfig, clusterProvider, logFile)
+		result.RunID = manifest.RunID
+		if n := attempts[unitOf(result)]; n > 0 {
+			result.Attempt = n + 1
+		}
+
+		fmt.Printf("\033[32mWorker %d: Completed %s for %s in %s\033[0m\n",
+			workerID,
+			unit.llmConfig.ID,
+			unit.taskID,
+			time.Since(start).Round(time.Second),
+		)
+
+		if err := writeToYAMLFile(filepath.Join(taskOutputDir, "results.yaml"), result); err != nil {
+			return fmt.Errorf("writing results to file: %w", err)
+		}
+		resultsCh <- result
+		return nil
 	}
-	close(taskCh)
 
 	// Create a wait group to track all workers
 	var wg sync.WaitGroup
 
-	fmt.Printf("Running tasks with concurrency: %d\n", config.Concurrency)
+	fmt.Printf("Running %d runs with concurrency: %d\n", len(units), config.Concurrency)
 
 	// Start workers based on concurrency setting
 	for i := 0; i < config.Concurrency; i++ {
@@ -141,50 +259,17 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 		go func(workerID int) {
 			defer wg.Done()
 
-			for job := range taskCh {
-				fmt.Printf("Worker %d: Evaluating task: %s\n", workerID, job.taskID)
-
-				for _, llmConfig := range config.LLMConfigs {
-					taskOutputDir := ""
-					if config.OutputDir != "" {
-						taskOutputDir = filepath.Join(config.OutputDir, job.taskID)
-						if err := os.MkdirAll(taskOutputDir, 0755); err != nil {
-							errorsCh <- fmt.Errorf("creating directory %q: %w", taskOutputDir, err)
-							return
-						}
-					}
-
-					var log io.Writer
-					if taskOutputDir != "" {
-						logPath := filepath.Join(taskOutputDir, "log.txt")
-						logFile, err := os.Create(logPath)
-						if err != nil {
-							errorsCh <- fmt.Errorf("creating log file %q: %w", logPath, err)
-							return
-						}
-						defer logFile.Close()
-						log = logFile
-					}
-
-					start := time.Now()
-					fmt.Printf("\033[36mWorker %d: Started %s for %s\033[0m\n", workerID, llmConfig.ID, job.taskID)
-
-					result := evaluateTask(ctx, config, job.taskID, job.task, llmConfig, clusterProvider, log)
-
-					fmt.Printf("\033[32mWorker %d: Completed %s for %s in %s\033[0m\n",
-						workerID,
-						llmConfig.ID,
-						job.taskID,
-						time.Since(start).Round(time.Second),
-					)
-
-					if taskOutputDir != "" {
-						if err := writeToYAMLFile(filepath.Join(taskOutputDir, "results.yaml"), result); err != nil {
-							errorsCh <- fmt.Errorf("writing results to file: %w", err)
-							return
-						}
-					}
-					resultsCh <- result
+			// After an interrupt no more units are handed out; the remaining ones are left for --resume.
+			for {
+				unit, ok := queue.next(ctx)
+				if !ok {
+					return
+				}
+				err := evaluateUnit(workerID, unit)
+				queue.done(unit)
+				if err != nil {
+					errorsCh <- err
+					return
 				}
 			}
 		}(i)
@@ -198,7 +283,7 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 	// Check if there were any errors
 	for err := range errorsCh {
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -208,22 +293,55 @@ func runEvaluation(ctx context.Context, config EvalConfig) error {
 		allResults = append(allResults, result)
 	}
 
+	if ctx.Err() != nil {
+		fmt.Printf("\033[33mRun interrupted after %d results; continue it with --resume\033[0m\n", len(allResults))
+		manifest.Interrupted = true
+	}
+
+	if err := writeIndex(config.OutputDir); err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	manifest.EndTime = &end
+	if config.clusterPool != nil {
+		// Closed first, so the statistics include the resets that were still in progress.
+		closeClusterPool(ctx, config.clusterPool)
+		stats := config.clusterPool.Stats()
+		manifest.Cluster.Pool = &stats
+	}
+	if err := writeRunManifest(config.OutputDir, manifest); err != nil {
+		return nil, err
+	}
+
 	printResults(allResults)
-	return nil
+	return allResults, nil
 }
 
 // writeToYAMLFile will encode the specified object as yaml, and write it to the file.
+// The file is replaced atomically, so an interrupted run never leaves it half written.
 func writeToYAMLFile(p string, obj any) error {
 	data, err := yaml.Marshal(obj)
 	if err != nil {
 		return fmt.Errorf("marshaling to yaml: %w", err)
 	}
-	if err := os.WriteFile(p, data, 0644); err != nil {
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("writing to file %q: %w", p, err)
+	}
+	if err := os.Rename(tmp, p); err != nil {
 		return fmt.Errorf("writing to file %q: %w", p, err)
 	}
 	return nil
 }
 
+// isTaskDir reports whether dir holds a task.yaml. Other directories of the tasks directory, such
+// as tasks/gatekeeper, which holds a suite of tasks of its own, are not tasks.
+func isTaskDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "task.yaml"))
+	return err == nil && !info.IsDir()
+}
+
 func loadTasks(config EvalConfig) (map[string]Task, error) {
 	tasks := make(map[string]Task)
 
@@ -250,6 +368,9 @@ func loadTasks(config EvalConfig) (map[string]Task, error) {
 		if taskFilter != nil && !taskFilter.MatchString(taskID) {
 			continue
 		}
+		if !isTaskDir(filepath.Join(config.TasksDir, taskID)) {
+			continue
+		}
 
 		taskFile := filepath.Join(config.TasksDir, taskID, "task.yaml")
 
@@ -269,12 +390,31 @@ func loadTasks(config EvalConfig) (map[string]Task, error) {
 			continue
 		}
 
+		if !config.selectsTask(&task) {
+			continue
+		}
+
 		tasks[taskID] = task
 	}
 
 	return tasks, nil
 }
 
+// selectsTask reports whether the task passes the --difficulty, --tags and --exclude-tags filters.
+func (config *EvalConfig) selectsTask(task *Task) bool {
+	if len(config.Difficulties) > 0 && !slices.Contains(config.Difficulties, task.Difficulty) {
+		return false
+	}
+	labels := task.Labels()
+	if len(config.Tags) > 0 && !slices.ContainsFunc(config.Tags, func(tag string) bool { return slices.Contains(labels, tag) }) {
+		return false
+	}
+	if slices.ContainsFunc(config.ExcludeTags, func(tag string) bool { return slices.Contains(labels, tag) }) {
+		return false
+	}
+	return true
+}
+
 // getLastNLines returns the last n lines of a string.
 func getLastNLines(s string, n int) (string, bool) {
 	lines := strings.Split(s, "\n")
@@ -284,28 +424,22 @@ func getLastNLines(s string, n int) (string, bool) {
 	return s, false
 }
 
-func evaluateTask(ctx context.Context, config EvalConfig, taskID string, task Task, llmConfig model.LLMConfig, clusterProvider cluster.Provider, log io.Writer) model.TaskResult {
-	result := model.TaskResult{
-		Task:      taskID,
-		LLMConfig: llmConfig,
-	}
+func evaluateTask(ctx context.Context, config EvalConfig, taskID string, task Task, llmConfig model.LLMConfig, clusterProvider cluster.Provider, log io.Writer) (result model.TaskResult) {
+	result = config.newResult(taskID, &task, llmConfig)
+	// Deferred first so that it runs last, once the result is final on every path.
+	defer result.ComputeScore()
 
-	// Timeout limit for the whole task (setup, agent actions, verify)
-	timeout := 10 * time.Minute
-	if task.Timeout != "" {
-		var err error
-		timeout, err = time.ParseDuration(task.Timeout)
-		if err != nil {
-			result.Result = "fail"
-			result.Error = fmt.Sprintf("parsing timeout: %v", err)
-			return result
-		}
+	timeout, err := task.TimeLimit()
+	if err != nil {
+		result.Result = "fail"
+		result.Error = err.Error()
+		return result
 	}
 
 	taskCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
-	taskOutputDir := filepath.Join(config.OutputDir, taskID)
+	taskOutputDir := config.taskOutputDir(taskID, &task, llmConfig)
 
 	var logBuffer bytes.Buffer
 	multiWriter := io.MultiWriter(&logBuffer)
@@ -313,47 +447,31 @@ func evaluateTask(ctx context.Context, config EvalConfig, taskID string, task Ta
 		multiWriter = io.MultiWriter(log, &logBuffer)
 	}
 
-	x := &TaskExecution{
-		AgentBin:        config.AgentBin,
-		kubeConfig:      config.KubeConfig,
-		result:          &result,
-		llmConfig:       llmConfig,
-		log:             multiWriter,
-		task:            &task,
-		taskID:          taskID,
-		taskOutputDir:   taskOutputDir,
-		clusterProvider: clusterProvider,
-	}
-
-	// Set the isolation mode to cluster if vcluster is used.
-	if config.ClusterProvider == "vcluster" {
-		x.task.Isolation = IsolationModeCluster
-	}
-
-	taskDir := filepath.Join(config.TasksDir, taskID)
-	taskDirAbs, err := filepath.Abs(taskDir)
+	x, err := newTaskExecution(config, taskID, task, llmConfig, clusterProvider, multiWriter, &result)
 	if err != nil {
 		result.Result = "fail"
 		result.Error = err.Error()
 		return result
 	}
-	taskDir = taskDirAbs
-	x.taskDir = taskDir
 
-	defer func() {
-		if err := x.runCleanup(context.Background()); err != nil {
-			fmt.Printf("Warning: cleanup failed for task %s: %v\n", taskID, err)
-		}
-	}()
+	defer x.cleanupWithTimeout(ctx)
+	// Deferred after the cleanup so that it runs first: an interrupt during cleanup does not cancel a completed task.
+	defer recordCancellation(ctx, &result)
 
 	if err := x.runSetup(taskCtx); err != nil {
-		// Unexpected error
+		if errors.Is(err, errTaskSkipped) {
+			return result
+		}
+		// Setup errors (including ready gates that never pass) are not charged to the model
+		result.Result = "error"
 		result.Error = err.Error()
 		return result
 	}
 
 	// Run the agent
-	agentOutput, err := x.runAgent(taskCtx)
+	agentOutput, _, err := x.runAgent(taskCtx)
+	// The trace of a failed or timed out run is just as telling.
+	result.Metrics = x.traceMetrics()
 	if err != nil {
 		if taskCtx.Err() == context.DeadlineExceeded {
 			result.Result = "fail"
@@ -376,71 +494,43 @@ func evaluateTask(ctx context.Context, config EvalConfig, taskID string, task Ta
 	}
 
 	var expectationFailures []model.Failure
+	var judgeErr error
 
-	if len(task.Expect) > 0 {
-		// find the output after the last run command and search it
-		var lastCmdOutput string
-		lastToolRunIndex := strings.LastIndex(agentOutput, "Running:")
-		if lastToolRunIndex == -1 {
-			// if no tool run found, parse the entire output
-			lastCmdOutput = agentOutput
-		} else {
-			remaining := agentOutput[lastToolRunIndex:]
-			newlineIndex := strings.Index(remaining, "\n")
-			if newlineIndex != -1 {
-				lastCmdOutput = remaining[newlineIndex+1:]
-			}
-			// if no newline, lastCmdOutput is empty string
-		}
-
-		for _, expect := range task.Expect {
-			if expect.Contains != "" {
-				re, err := regexp.Compile(expect.Contains)
-				if err != nil {
-					expectationFailures = append(expectationFailures, model.Failure{
-						Message: fmt.Sprintf("invalid regex %q in task spec: %v", expect.Contains, err),
-					})
-					continue
-				}
-				if !re.MatchString(lastCmdOutput) {
-					expectationFailures = append(expectationFailures, model.Failure{
-						Message: fmt.Sprintf("regex %q did not match output %q", expect.Contains, lastCmdOutput),
-					})
-				}
-			}
-			if expect.NotContains != "" {
-				re, err := regexp.Compile(expect.NotContains)
-				if err != nil {
-					expectationFailures = append(expectationFailures, model.Failure{
-						Message: fmt.Sprintf("invalid regex %q in task spec: %v", expect.NotContains, err),
-					})
-					continue
-				}
-				if re.MatchString(lastCmdOutput) {
-					expectationFailures = append(expectationFailures, model.Failure{
-						Message: fmt.Sprintf("regex %q matched output %q (should not have matched)", expect.NotContains, lastCmdOutput),
-					})
-				}
-			}
+	if len(x.task.Expect) > 0 {
+		question, err := x.taskRequest()
+		if err != nil {
+			result.Result = "error"
+			result.Error = err.Error()
+			return result
 		}
+		checks, setScores, verdicts, err := checkExpectations(x.task.Expect, outputAfterLastCommand(agentOutput), x.judgeFunc(taskCtx, question, 0))
+		judgeErr = err
+		result.Checks = append(result.Checks, checks...)
+		result.SetScores = append(result.SetScores, setScores...)
+		result.JudgeVerdicts = append(result.JudgeVerdicts, verdicts...)
+		expectationFailures = failedChecks(checks)
 
 		if len(expectationFailures) == 0 {
 			fmt.Printf("\nAll output expectations met\n")
 		}
 	}
 
-	verifierSucceeded := false
+	hasVerifier := x.task.Verifier != "" || len(x.task.Verify) > 0
+	verifierFailed := false
 	// Run verifier if specified
-	if task.Verifier != "" {
-		verifierPath := filepath.Join(taskDir, task.Verifier)
+	if x.task.Verifier != "" {
+		verifierPath := filepath.Join(x.taskDir, x.task.Verifier)
 		cmd := exec.CommandContext(taskCtx, verifierPath)
-		cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", x.kubeConfig))
+		cmd.Env = x.scriptEnv()
 		fmt.Printf("\nRunning verifier for task %s\n", taskID)
 
+		check := model.Check{Name: x.task.Verifier, Kind: "verifier", Weight: model.CheckWeight(x.task.VerifierWeight)}
 		err := x.runCommand(cmd)
-		if err == nil {
-			verifierSucceeded = true
-		} else {
+		if err != nil && check.Weight == 0 {
+			// An informational check does not decide the result.
+			check.Message = err.Error()
+		} else if err != nil {
+			verifierFailed = true
 			const maxLogLines = 20
 			logString := logBuffer.String()
 			logTail, truncated := getLastNLines(logString, maxLogLines)
@@ -451,11 +541,58 @@ func evaluateTask(ctx context.Context, config EvalConfig, taskID string, task Ta
 				failureMessage += fmt.Sprintf("\n... (log truncated, full log at %s)", logPath)
 			}
 			result.AddFailure("%s", failureMessage)
+			check.Message = err.Error()
+		} else {
+			check.Pass()
+		}
+		result.Checks = append(result.Checks, check)
+	}
+
+	// Evaluate declarative assertions if specified
+	if len(x.task.Verify) > 0 {
+		fmt.Printf("\nEvaluating %d assertions for task %s\n", len(x.task.Verify), taskID)
+		result.Assertions = verify.Evaluate(taskCtx, verify.NewKubectl(x.kubeConfig), x.task.Verify)
+		for i, assertion := range result.Assertions {
+			check := model.Check{Name: assertion.Assertion, Kind: "assertion", Weight: model.CheckWeight(x.task.Verify[i].Weight), Message: assertion.Message}
+			if assertion.Passed {
+				check.Pass()
+			} else if check.Weight > 0 {
+				verifierFailed = true
+				result.AddFailure("assertion %q failed: %s", assertion.Assertion, assertion.Message)
+			}
+			result.Checks = append(result.Checks, check)
 		}
 	}
+	verifierSucceeded := hasVerifier && !verifierFailed
 
-	expectationsMet := len(task.Expect) > 0 && len(expectationFailures) == 0
-	if verifierSucceeded || expectationsMet {
+	for _, step := range result.Steps {
+		if judgeErr == nil && step.Result == "error" {
+			judgeErr = fmt.Errorf("step %d: %s", step.Step, step.Reason)
+		}
+	}
+	if judgeErr != nil {
+		result.Result = "error"
+		result.Error = judgeErr.Error()
+		return result
+	}
+
+	expectationsMet := len(x.task.Expect) > 0 && len(expectationFailures) == 0
+	// Tasks graded only by per-step checks succeed when every step passed.
+	stepsOnly := !hasVerifier && len(x.task.Expect) == 0 && len(result.Steps) > 0
+	if stepsOnly && !result.StepsEvaluated() {
+		result.Result = "error"
+		result.Error = "the task is graded only by per-step checks, which require an interactive agent"
+		return result
+	}
+	if result.FailedStep != 0 {
+		result.Result = "fail"
+		for _, step := range result.Steps {
+			for _, failure := range step.Failures {
+				result.AddFailure("step %d: %s", step.Step, failure.Message)
+			}
+		}
+		result.Failures = append(result.Failures, expectationFailures...)
+	} else if verifierSucceeded || expectationsMet || stepsOnly {
 		result.Result = "success"
 	} else {
 		result.Result = "fail"
@@ -465,6 +602,81 @@ func evaluateTask(ctx context.Context, config EvalConfig, taskID string, task Ta
 	return result
 }
 
+// TimeLimit is the time limit for the whole task (setup, agent actions, verify).
+func (t *Task) TimeLimit() (time.Duration, error) {
+	if t.Timeout == "" {
+		return 10 * time.Minute, nil
+	}
+	timeout, err := time.ParseDuration(t.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("parsing timeout: %w", err)
+	}
+	return timeout, nil
+}
+
+// newTaskExecution prepares a run of the task: it resolves the task directory and renders
+// this run's instance of the task (variables and, in IsolationModeNamespace, the namespace).
+func newTaskExecution(config EvalConfig, taskID string, task Task, llmConfig model.LLMConfig, clusterProvider cluster.Provider, log io.Writer, result *model.TaskResult) (*TaskExecution, error) {
+	x := &TaskExecution{
+		AgentBin:        config.AgentBin,
+		kubeConfig:      config.KubeConfig,
+		result:          result,
+		llmConfig:       llmConfig,
+		log:             log,
+		task:            &task,
+		taskID:          taskID,
+		taskOutputDir:   config.taskOutputDir(taskID, &task, llmConfig),
+		clusterProvider: clusterProvider,
+		clusterPool:     config.clusterPool,
+
+		userSimulator:      config.UserSimulator,
+		userSimulatorModel: config.UserSimulatorModel,
+
+		judgeProvider: config.Judge,
+		judgeModel:    config.JudgeModel,
+	}
+
+	// Tasks isolated by namespace still get their namespace, inside the cluster they are given with vcluster.
+	if task.Isolation == IsolationModeNamespace {
+		x.namespace = isolatedNamespaceName(taskID)
+	}
+
+	// Set the isolation mode to cluster if vcluster is used.
+	if config.ClusterProvider == "vcluster" {
+		x.task.Isolation = IsolationModeCluster
+	}
+
+	taskDir, err := filepath.Abs(filepath.Join(config.TasksDir, taskID))
+	if err != nil {
+		return nil, err
+	}
+	x.taskDir = taskDir
+
+	// Every repetition samples its own variables, reproducibly.
+	seedKey := taskID
+	if config.Iterations > 1 && task.iteration > 1 {
+		seedKey = fmt.Sprintf("%s/%d", taskID, task.iteration)
+	}
+
+	builtins := map[string]string{}
+	if x.namespace != "" {
+		builtins[taskNamespaceVariable] = x.namespace
+	}
+
+	// Sample the task variables and render this run's instance of the task.
+	if len(task.Variables) > 0 || len(builtins) > 0 {
+		instance, vars, err := task.Instantiate(taskDir, instanceSeed(config.Seed, seedKey), builtins)
+		if err != nil {
+			return nil, fmt.Errorf("instantiating task: %w", err)
+		}
+		x.task = instance
+		x.variables = vars
+		result.Seed = config.Seed
+		result.Variables = vars
+	}
+	return x, nil
+}
+
 type TaskExecution struct {
 	// kubeConfig is the path to the kubeconfig file we should use.
 	// It will be created in IsolationModeCluster
@@ -483,49 +695,111 @@ type TaskExecution struct {
 	// taskOutputDir is where we can create artifacts or write logs while executing the task
 	taskOutputDir string
 
+	// variables holds the sampled task variables, exported to task scripts.
+	variables map[string]string
+
+	// namespace is the namespace created for the task in IsolationModeNamespace.
+	namespace string
+
+	// agentKubeConfig is the kubeconfig handed to the agent, if it differs from kubeConfig.
+	// In IsolationModeNamespace it is bound to a ServiceAccount with rights only in namespace.
+	agentKubeConfig string
+
+	// userSimulator and userSimulatorModel select the model that plays the user (see EvalConfig.UserSimulator).
+	userSimulator      string
+	userSimulatorModel string
+
+	// judgeProvider and judgeModel select the judge for judge expectations (see EvalConfig.Judge);
+	// judge is created when first needed.
+	judgeProvider string
+	judgeModel    string
+	judge         judge.Judge
+
 	// cleanupFunctions are a set of cleanupFunctions we run to undo anything we ran
-	cleanupFunctions []func() error
+	cleanupFunctions []func(ctx context.Context) error
 
 	clusterProvider cluster.Provider
+
+	// clusterPool, if set, provides the cluster in IsolationModeCluster instead of clusterProvider.
+	clusterPool *pool.Pool
+}
+
+// errTaskSkipped is returned by runSetup when the cluster does not meet the task requirements;
+// the result has already been marked as skipped.
+var errTaskSkipped = errors.New("task skipped")
+
+// checkRequirements verifies the task requirements against the cluster, marking the result as skipped if any are unmet.
+func (x *TaskExecution) checkRequirements(ctx context.Context) error {
+	if x.task.Requires == nil {
+		return nil
+	}
+	unmet, err := x.task.Requires.Check(ctx, x.kubeConfig)
+	if err != nil {
+		return fmt.Errorf("checking task requirements: %w", err)
+	}
+	if len(unmet) > 0 {
+		fmt.Printf("Skipping task %s: %s\n", x.taskID, strings.Join(unmet, "; "))
+		x.result.Skip(unmet...)
+		return errTaskSkipped
+	}
+	return nil
 }
 
 func (x *TaskExecution) runSetup(ctx context.Context) error {
 	log := klog.FromContext(ctx)
 
-	// Create cluster if requested
-	if x.task.Isolation == IsolationModeCluster {
-		kubeconfigPath := filepath.Join(x.taskDir, "kubeconfig.yaml")
-		x.kubeConfig = kubeconfigPath
-
-		clusterName := fmt.Sprintf("k8s-ai-bench-%s", x.taskID)
-		// Truncate to avoid issues with vcluster resource names (hostPod names can trigger 63 char limit)
-		if len(clusterName) > 45 {
-			hash := sha256.Sum256([]byte(clusterName))
-			shortHash := hex.EncodeToString(hash[:])[:6]
-			clusterName = fmt.Sprintf("%s-%s", clusterName[:38], shortHash)
+	// Check requirements against the shared cluster before creating anything in it;
+	// isolated clusters are checked once they exist, but first we rule out what the
+	// provider's clusters cannot offer, to avoid creating a cluster for nothing.
+	if x.task.Isolation != IsolationModeCluster {
+		if err := x.checkRequirements(ctx); err != nil {
+			return err
 		}
-		log.Info("creating cluster", "name", clusterName)
-
-		if err := x.clusterProvider.Create(clusterName); err != nil {
-			return fmt.Errorf("failed to create isolated cluster %q: %w", clusterName, err)
+	} else if x.task.Requires != nil {
+		if unmet := x.task.Requires.Unmet(x.clusterProvider.Capabilities()); len(unmet) > 0 {
+			fmt.Printf("Skipping task %s: %s\n", x.taskID, strings.Join(unmet, "; "))
+			x.result.Skip(unmet...)
+			return errTaskSkipped
 		}
+	}
 
-		x.cleanupFunctions = append(x.cleanupFunctions, func() error {
-			if err := os.Remove(kubeconfigPath); err != nil {
+	// Create (or lease) a cluster if requested
+	if x.task.Isolation == IsolationModeCluster {
+		kubeconfigPath := filepath.Join(x.taskDir, "kubeconfig.yaml")
+		x.kubeConfig = kubeconfigPath
+		x.cleanupFunctions = append(x.cleanupFunctions, func(ctx context.Context) error {
+			if err := os.Remove(kubeconfigPath); err != nil && !os.IsNotExist(err) {
 				log.Error(err, "failed to remove kubeconfig file", "path", kubeconfigPath)
 			}
-			return x.clusterProvider.Delete(clusterName)
+			return nil
 		})
 
-		// Get kubeconfig and write it to the file
-		kubeconfigBytes, err := x.clusterProvider.GetKubeconfig(clusterName)
+		var clusterName string
+		var kubeconfigBytes []byte
+		var err error
+		if x.clusterPool != nil {
+			clusterName, kubeconfigBytes, err = x.leaseCluster(ctx)
+		} else {
+			clusterName, kubeconfigBytes, err = x.createCluster(ctx)
+		}
 		if err != nil {
-			return fmt.Errorf("failed to get kubeconfig for isolated cluster %q: %w", clusterName, err)
+			return err
 		}
 
 		if err := os.WriteFile(kubeconfigPath, kubeconfigBytes, 0644); err != nil {
 			return fmt.Errorf("failed to write kubeconfig for isolated cluster %q: %w", clusterName, err)
 		}
+
+		if err := x.checkRequirements(ctx); err != nil {
+			return err
+		}
+	}
+
+	// Create namespace if requested
+	if x.namespace != "" {
+		if err := x.setupNamespaceIsolation(ctx); err != nil {
+			return err
+		}
 	}
 
 	// Run setup if specified
@@ -533,25 +807,87 @@ func (x *TaskExecution) runSetup(ctx context.Context) error {
 		setupPath := filepath.Join(x.taskDir, x.task.Setup)
 		cmd := exec.CommandContext(ctx, setupPath)
 		cmd.Dir = x.taskDir
-		cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", x.kubeConfig))
+		cmd.Env = x.scriptEnv()
 
 		if err := x.runCommand(cmd); err != nil {
 			return err
 		}
 	}
 
+	// Wait for the ready gates, so the agent never starts against a half-initialized environment
+	if len(x.task.Ready) > 0 {
+		fmt.Printf("\nWaiting for task %s to be ready\n", x.taskID)
+		var notReady []string
+		for _, r := range verify.Evaluate(ctx, verify.NewKubectl(x.kubeConfig), x.task.Ready) {
+			if !r.Passed {
+				notReady = append(notReady, fmt.Sprintf("%s: %s", r.Assertion, r.Message))
+			}
+		}
+		if len(notReady) > 0 {
+			return fmt.Errorf("setup did not become ready: %s", strings.Join(notReady, "; "))
+		}
+	}
+
 	return nil
 }
 
+// createCluster creates a cluster for this execution of the task, to be deleted on cleanup,
+// and returns its name and kubeconfig.
+func (x *TaskExecution) createCluster(ctx context.Context) (string, []byte, error) {
+	log := klog.FromContext(ctx)
+
+	clusterName := fmt.Sprintf("k8s-ai-bench-%s", x.taskID)
+	// Truncate to avoid issues with vcluster resource names (hostPod names can trigger 63 char limit)
+	if len(clusterName) > 45 {
+		hash := sha256.Sum256([]byte(clusterName))
+		shortHash := hex.EncodeToString(hash[:])[:6]
+		clusterName = fmt.Sprintf("%s-%s", clusterName[:38], shortHash)
+	}
+	log.Info("creating cluster", "name", clusterName)
+
+	// Registered before creating the cluster, so that a creation that is interrupted is cleaned up too.
+	x.cleanupFunctions = append(x.cleanupFunctions, func(ctx context.Context) error {
+		return deleteCluster(ctx, x.clusterProvider, clusterName)
+	})
+
+	if err := x.clusterProvider.Create(clusterName); err != nil {
+		return "", nil, fmt.Errorf("failed to create isolated cluster %q: %w", clusterName, err)
+	}
+
+	kubeconfigBytes, err := x.clusterProvider.GetKubeconfig(clusterName)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get kubeconfig for isolated cluster %q: %w", clusterName, err)
+	}
+	return clusterName, kubeconfigBytes, nil
+}
+
+// leaseCluster leases a cluster from the pool for this execution of the task, to be returned on cleanup,
+// and returns its name and kubeconfig.
+func (x *TaskExecution) leaseCluster(ctx context.Context) (string, []byte, error) {
+	lease, err := x.clusterPool.Lease(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to lease a cluster from the pool: %w", err)
+	}
+	klog.FromContext(ctx).Info("leased cluster", "name", lease.Name())
+
+	x.cleanupFunctions = append(x.cleanupFunctions, func(ctx context.Context) error {
+		// After an infrastructure error or an interrupt the cluster may be in any state, so it is replaced.
+		recycle := x.result.Result == "error" || x.result.Result == model.ResultCancelled
+		x.clusterPool.Return(ctx, lease, recycle)
+		return nil
+	})
+	return lease.Name(), lease.Kubeconfig(), nil
+}
+
 func (x *TaskExecution) runCleanup(ctx context.Context) error {
 	var errs []error
 
-	// Run cleanup if specified
-	if x.task.Cleanup != "" {
+	// Run cleanup if specified; skipped tasks never ran their setup
+	if x.task.Cleanup != "" && x.result.Result != model.ResultSkipped {
 		cleanupPath := filepath.Join(x.taskDir, x.task.Cleanup)
 		cmd := exec.CommandContext(ctx, cleanupPath)
 		cmd.Dir = x.taskDir
-		cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", x.kubeConfig))
+		cmd.Env = x.scriptEnv()
 
 		if err := x.runCommand(cmd); err != nil {
 			fmt.Printf("Warning: cleanup failed for task %s: %v\n", x.taskID, err)
@@ -559,7 +895,7 @@ func (x *TaskExecution) runCleanup(ctx context.Context) error {
 	}
 
 	for _, cleanup := range x.cleanupFunctions {
-		if err := cleanup(); err != nil {
+		if err := cleanup(ctx); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -567,70 +903,308 @@ func (x *TaskExecution) runCleanup(ctx context.Context) error {
 	return errors.Join(errs...)
 }
 
-func (x *TaskExecution) runAgent(ctx context.Context) (string, error) {
-	tracePath := filepath.Join(x.taskOutputDir, "trace.yaml")
+// tracePath is where the agent writes its trace.
+func (x *TaskExecution) tracePath() string {
+	return filepath.Join(x.taskOutputDir, "trace.yaml")
+}
 
-	args := []string{
-		"--kubeconfig", x.kubeConfig,
-		"--llm-provider", x.llmConfig.ProviderID,
-		fmt.Sprintf("--enable-tool-use-shim=%t", x.llmConfig.EnableToolUseShim),
-		fmt.Sprintf("--quiet=%t", x.llmConfig.Quiet),
-		"--model", x.llmConfig.ModelID,
-		"--trace-path", tracePath,
-		"--skip-permissions",
-		"--show-tool-output",
+// traceMetrics returns the metrics of the agent's trace, or nil if it wrote none.
+func (x *TaskExecution) traceMetrics() *model.TraceMetrics {
+	transcript, err := trace.ParseFile(x.tracePath())
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			fmt.Fprintf(os.Stderr, "Warning: could not read the trace of task %s: %v\n", x.taskID, err)
+		}
+		return nil
 	}
-	if x.llmConfig.McpClient {
-		args = append(args, "--mcp-client")
+	metrics := transcript.Metrics()
+	return &metrics
+}
+
+func (x *TaskExecution) runAgent(ctx context.Context) (string, []agentTurn, error) {
+	tracePath := x.tracePath()
+
+	a, err := agent.New(x.llmConfig.Agent, x.AgentBin)
+	if err != nil {
+		return "", nil, err
 	}
 
-	stdinReader, stdinWriter := io.Pipe()
+	waiter := &turnWaiter{
+		recorder:    &turnRecorder{},
+		idleTimeout: defaultTurnIdleTimeout,
+		tracePath:   tracePath,
+	}
+	if x.task.TurnIdleTimeout != "" {
+		idleTimeout, err := time.ParseDuration(x.task.TurnIdleTimeout)
+		if err != nil {
+			return "", nil, fmt.Errorf("parsing turnIdleTimeout: %w", err)
+		}
+		waiter.idleTimeout = idleTimeout
+	}
+	if x.task.PromptMarker != "" {
+		re, err := regexp.Compile(x.task.PromptMarker)
+		if err != nil {
+			return "", nil, fmt.Errorf("compiling promptMarker %q: %w", x.task.PromptMarker, err)
+		}
+		waiter.promptMarker = re
+	}
+
+	// Tasks that must be run turn by turn are, even in quiet mode; others keep the configured mode.
+	llmConfig := x.llmConfig
+	if x.task.runsTurnByTurn() {
+		llmConfig.Quiet = false
+	}
+	// We can only converse turn-by-turn with an interactive agent.
+	interactive := a.Interactive(llmConfig)
+
+	user, err := x.newUserSimulator(ctx, interactive)
+	if err != nil {
+		return "", nil, err
+	}
 
-	cmd := exec.CommandContext(ctx,
-		x.AgentBin,
-		args...,
-	)
-	cmd.Stdin = stdinReader
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 	var stdoutBuffer bytes.Buffer
-	if x.log != nil {
-		cmd.Stdout = io.MultiWriter(cmd.Stdout, x.log, &stdoutBuffer)
-		cmd.Stderr = io.MultiWriter(cmd.Stderr, x.log)
+	stdout := io.MultiWriter(os.Stdout, x.log, &stdoutBuffer, waiter.recorder)
+	stderr := io.MultiWriter(os.Stderr, x.log)
+
+	agentKubeConfig := x.kubeConfig
+	if x.agentKubeConfig != "" {
+		agentKubeConfig = x.agentKubeConfig
+	}
+
+	session, err := a.Start(ctx, agent.Request{
+		TaskID:     x.taskID,
+		TaskDir:    x.taskDir,
+		KubeConfig: agentKubeConfig,
+		LLM:        llmConfig,
+		TracePath:  tracePath,
+		Output:     stdout,
+		Stderr:     stderr,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if signaler, ok := session.(agent.TurnSignaler); ok {
+		waiter.turnDone = signaler.TurnDone
+	}
+	if interactive && (len(x.task.Script) > 1 || user != nil) {
+		waiter.waitStarted(ctx, session.Done())
+	}
+
+	var turns []agentTurn
+	var pending []int
+	var prompts []string
+	for i, step := range x.task.Script {
+		prompt, err := step.ResolvePrompt(x.taskDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error resolving prompt: %v\n", err)
+			x.result.AddFailure("failed to resolve prompt: %v", err)
+			break
+		}
+		pending = append(pending, i)
+		prompts = append(prompts, prompt)
+		waiter.recorder.sending()
+		if err := session.Send(prompt); err != nil {
+			// The agent has exited; its exit status is reported by Err.
+			break
+		}
+		// Without a simulated user there is nothing more to say after the last step.
+		if !interactive || (i == len(x.task.Script)-1 && user == nil) {
+			continue
+		}
+
+		waiter.wait(ctx, session.Done())
+		output := waiter.recorder.take()
+		if user != nil {
+			output = x.answerQuestions(ctx, user, i, output, session, waiter)
+		}
+		fmt.Printf("\nAgent finished turn %d of task %s\n", i+1, x.taskID)
+		turn := agentTurn{Steps: pending, Prompt: strings.Join(prompts, "\n"), Output: output}
+		x.checkTurn(ctx, turn)
+		turns = append(turns, turn)
+		pending, prompts = nil, nil
+	}
+	session.Close()
+
+	<-session.Done()
+
+	// Whatever the agent printed after the last step is sent belongs to the final turn,
+	// which is checked even if the agent then failed.
+	if len(pending) > 0 {
+		turn := agentTurn{Steps: pending, Prompt: strings.Join(prompts, "\n"), Output: waiter.recorder.take()}
+		x.checkTurn(ctx, turn)
+		turns = append(turns, turn)
+	}
+
+	if err := session.Err(); err != nil {
+		return "", nil, err
+	}
+	return stdoutBuffer.String(), turns, nil
+}
+
+// newUserSimulator returns the simulated user for the task, or nil if the task does not declare one.
+func (x *TaskExecution) newUserSimulator(ctx context.Context, interactive bool) (*usersim.Simulator, error) {
+	if x.task.User == nil {
+		return nil, nil
+	}
+	if !interactive {
+		return nil, fmt.Errorf("task %s declares a simulated user, which needs an interactive agent", x.taskID)
+	}
+	var client llm.Client
+	if x.userSimulator != "" && x.userSimulator != "stub" {
+		var err error
+		client, err = llm.New(ctx, x.userSimulator, x.userSimulatorModel)
+		if err != nil {
+			return nil, fmt.Errorf("creating user simulator: %w", err)
+		}
+	}
+	request, err := x.taskRequest()
+	if err != nil {
+		return nil, err
 	}
+	return usersim.New(x.task.User, request, client)
+}
 
-	cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", x.kubeConfig))
+// taskRequest returns everything the user asks for in the task script.
+func (x *TaskExecution) taskRequest() (string, error) {
+	var prompts []string
+	for _, step := range x.task.Script {
+		prompt, err := step.ResolvePrompt(x.taskDir)
+		if err != nil {
+			return "", err
+		}
+		prompts = append(prompts, prompt)
+	}
+	return strings.Join(prompts, "\n"), nil
+}
 
-	go func() {
-		// TODO: Wait for idle between sending steps?
-		for _, step := range x.task.Script {
-			prompt, err := step.ResolvePrompt(x.taskDir)
+// judgeFunc returns the grader for the judge expectations of the task (or of a script step, if step is set)
+// in response to question. The judge is created on first use, so tasks without judge expectations need no credentials.
+func (x *TaskExecution) judgeFunc(ctx context.Context, question string, step int) judgeFunc {
+	return func(expect *JudgeExpectation, output string) (model.JudgeVerdict, error) {
+		if x.judge == nil {
+			j, err := judge.New(ctx, x.judgeProvider, x.judgeModel)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error resolving prompt: %v\n", err)
-				x.result.AddFailure("failed to resolve prompt: %v", err)
-				stdinWriter.Close()
-				return
+				return model.JudgeVerdict{}, fmt.Errorf("creating judge: %w", err)
 			}
-			fmt.Fprintf(stdinWriter, "%s\n", prompt)
+			x.judge = j
+		}
+		name := x.judgeProvider
+		if name != judge.Stub {
+			name += "/" + x.judgeModel
 		}
-		stdinWriter.Close()
-	}()
+		verdict, err := x.judge.Judge(ctx, judge.Request{
+			Question:  question,
+			Rubric:    expect.Rubric,
+			Reference: expect.Reference,
+			Answer:    output,
+		})
+		if err != nil {
+			return model.JudgeVerdict{}, err
+		}
+		return model.JudgeVerdict{Judge: name, Rubric: expect.Rubric, Step: step, Score: verdict.Score, Rationale: verdict.Rationale}, nil
+	}
+}
 
-	if err := cmd.Run(); err != nil {
-		return "", err
+// answerQuestions replies to the agent, for as long as the simulated user is willing, to questions
+// the agent ends its turn with. It returns the output of the whole turn, including the follow-ups.
+func (x *TaskExecution) answerQuestions(ctx context.Context, user *usersim.Simulator, step int, output string, session agent.Session, waiter *turnWaiter) string {
+	turnOutput := output
+	for {
+		question, ok := user.Question(output)
+		if !ok {
+			return turnOutput
+		}
+		answer, err := user.Answer(ctx, question)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: user simulator failed for task %s: %v\n", x.taskID, err)
+			return turnOutput
+		}
+		fmt.Printf("\nSimulated user answered a question in turn %d of task %s: %s\n", step+1, x.taskID, answer)
+		x.result.Clarifications = append(x.result.Clarifications, model.Clarification{Step: step + 1, Question: question, Answer: answer})
+		x.result.ClarificationRounds = len(x.result.Clarifications)
+		waiter.recorder.sending()
+		if err := session.Send(answer); err != nil {
+			return turnOutput
+		}
+		waiter.wait(ctx, session.Done())
+		output = waiter.recorder.take()
+		turnOutput += output
+	}
+}
+
+// checkTurn evaluates the expectations and verifiers of the script steps answered by a turn,
+// against the output of that turn and the cluster state right after it.
+func (x *TaskExecution) checkTurn(ctx context.Context, turn agentTurn) {
+	for _, i := range turn.Steps {
+		step := x.task.Script[i]
+		if len(step.Expect) == 0 && step.Verifier == "" {
+			continue
+		}
+		if len(turn.Steps) > 1 {
+			// A non-interactive agent answers all the steps at once: there is no output or
+			// cluster state of this step alone to check.
+			fmt.Printf("\nNot evaluating the checks of step %d of task %s: the agent is not interactive\n", i+1, x.taskID)
+			x.result.Steps = append(x.result.Steps, model.StepResult{
+				Step:   i + 1,
+				Result: model.StepNotEvaluated,
+				Reason: "the agent is not interactive, so it answered all the steps in a single turn",
+			})
+			continue
+		}
+
+		stepResult := model.StepResult{Step: i + 1}
+		checks, setScores, verdicts, judgeErr := checkExpectations(step.Expect, outputAfterLastCommand(turn.Output), x.judgeFunc(ctx, turn.Prompt, i+1))
+		x.result.SetScores = append(x.result.SetScores, setScores...)
+		x.result.JudgeVerdicts = append(x.result.JudgeVerdicts, verdicts...)
+
+		if step.Verifier != "" {
+			verifierPath := filepath.Join(x.taskDir, step.Verifier)
+			cmd := exec.CommandContext(ctx, verifierPath)
+			cmd.Dir = x.taskDir
+			cmd.Env = append(x.scriptEnv(), fmt.Sprintf("STEP=%d", i+1))
+			fmt.Printf("\nRunning verifier for step %d of task %s\n", i+1, x.taskID)
+
+			check := model.Check{Name: step.Verifier, Kind: "verifier", Weight: model.CheckWeight(step.VerifierWeight)}
+			if err := x.runCommand(cmd); err != nil {
+				check.Message = fmt.Sprintf("step verifier failed: %v", err)
+			} else {
+				check.Pass()
+			}
+			checks = append(checks, check)
+		}
+
+		for j := range checks {
+			checks[j].Step = i + 1
+		}
+		x.result.Checks = append(x.result.Checks, checks...)
+		stepResult.Failures = failedChecks(checks)
+
+		if judgeErr != nil {
+			stepResult.Result = "error"
+			stepResult.Reason = judgeErr.Error()
+		} else if len(stepResult.Failures) == 0 {
+			stepResult.Result = "success"
+		} else {
+			stepResult.Result = "fail"
+			if x.result.FailedStep == 0 {
+				x.result.FailedStep = i + 1
+			}
+		}
+		x.result.Steps = append(x.result.Steps, stepResult)
 	}
+}
 
-	return stdoutBuffer.String(), nil
+// scriptEnv returns the environment for task scripts (setup, verifier and cleanup),
+// which includes the sampled task variables.
+func (x *TaskExecution) scriptEnv() []string {
+	env := append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", x.kubeConfig))
+	return append(env, variableEnv(x.variables)...)
 }
 
 func (x *TaskExecution) runCommand(cmd *exec.Cmd) error {
 	fmt.Printf("\nRunning command: %s\n", strings.Join(cmd.Args, " "))
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if x.log != nil {
-		cmd.Stdout = io.MultiWriter(cmd.Stdout, x.log)
-		cmd.Stderr = io.MultiWriter(cmd.Stderr, x.log)
-	}
+	cmd.Stdout = io.MultiWriter(os.Stdout, x.log)
+	cmd.Stderr = io.MultiWriter(os.Stderr, x.log)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("running command %v: %w", strings.Join(cmd.Args, " "), err)
 	}
@@ -643,8 +1217,17 @@ func printResults(allResults []model.TaskResult) {
 
 	for _, result := range allResults {
 		fmt.Printf("\nTask: %s\n", result.Task)
+		if result.Variant != "" {
+			fmt.Printf("  Prompt Variant: %s\n", result.Variant)
+		}
+		if result.Iteration != 0 {
+			fmt.Printf("  Iteration: %d\n", result.Iteration)
+		}
 		fmt.Printf("  LLM Config: %+v\n", result.LLMConfig)
 		fmt.Printf("    %v\n", result.Result)
+		if result.SkipReason != "" {
+			fmt.Printf("    Skipped: %s\n", result.SkipReason)
+		}
 		if result.Error != "" {
 			fmt.Printf("    Error: %s\n", result.Error)
 		}
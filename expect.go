@@ -0,0 +1,204 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// outputAfterLastCommand returns the agent output after the last tool invocation,
+// which is normally the agent's final answer.
+// If no tool invocation is found, the entire output is returned.
+func outputAfterLastCommand(agentOutput string) string {
+	lastToolRunIndex := strings.LastIndex(agentOutput, "Running:")
+	if lastToolRunIndex == -1 {
+		return agentOutput
+	}
+	remaining := agentOutput[lastToolRunIndex:]
+	newlineIndex := strings.Index(remaining, "\n")
+	if newlineIndex == -1 {
+		return ""
+	}
+	return remaining[newlineIndex+1:]
+}
+
+// judgeFunc grades output against a judge expectation.
+type judgeFunc func(expect *JudgeExpectation, output string) (model.JudgeVerdict, error)
+
+// checkExpectations evaluates expectations against output, returning a check for each expectation,
+// the score of each set expectation and the verdict for each judge expectation (graded by grade).
+// A judge that cannot grade the output says nothing about the answer, so its error is returned
+// (after all the expectations are checked) rather than counted as a failed check.
+func checkExpectations(expect []Expectation, output string, grade judgeFunc) ([]model.Check, []model.SetScore, []model.JudgeVerdict, error) {
+	var checks []model.Check
+	var setScores []model.SetScore
+	var verdicts []model.JudgeVerdict
+	var judgeErr error
+	for _, expect := range expect {
+		weight := model.CheckWeight(expect.Weight)
+		if expect.Judge != nil {
+			check := model.Check{Name: fmt.Sprintf("judge %q", truncate(expect.Judge.Rubric, 60)), Kind: "judge", Weight: weight}
+			passScore := expect.Judge.PassScore
+			if passScore == 0 {
+				passScore = defaultJudgePassScore
+			}
+			verdict, err := grade(expect.Judge, output)
+			if err != nil {
+				check.Message = fmt.Sprintf("judge failed: %v", err)
+				if judgeErr == nil {
+					judgeErr = fmt.Errorf("judge failed: %w", err)
+				}
+			} else {
+				verdicts = append(verdicts, verdict)
+				// The judge's score is the partial credit, but only a score reaching passScore passes.
+				check.Score = verdict.Score
+				check.Passed = verdict.Score >= passScore
+				if !check.Passed {
+					check.Message = fmt.Sprintf("judge scored the answer %.2f, below %.2f: %s", verdict.Score, passScore, verdict.Rationale)
+				}
+			}
+			checks = append(checks, check)
+		}
+		if expect.Set != nil {
+			check := model.Check{Name: fmt.Sprintf("set %q", expect.Set.Pattern), Kind: "set", Weight: weight}
+			score, err := scoreSet(expect.Set, output)
+			if err != nil {
+				check.Message = fmt.Sprintf("invalid set expectation in task spec: %v", err)
+			} else {
+				setScores = append(setScores, score)
+				// Partial answers get partial credit, but only an exact match passes.
+				check.Score = score.F1
+				check.Passed = len(score.FalsePositives) == 0 && len(score.FalseNegatives) == 0
+				if !check.Passed {
+					check.Message = fmt.Sprintf("set %q did not match: false positives %v, false negatives %v (precision %.2f, recall %.2f, F1 %.2f)",
+						expect.Set.Pattern, score.FalsePositives, score.FalseNegatives, score.Precision, score.Recall, score.F1)
+				}
+			}
+			checks = append(checks, check)
+		}
+		if expect.Contains != "" {
+			check := model.Check{Name: fmt.Sprintf("contains %q", expect.Contains), Kind: "contains", Weight: weight}
+			re, err := regexp.Compile(expect.Contains)
+			if err != nil {
+				check.Message = fmt.Sprintf("invalid regex %q in task spec: %v", expect.Contains, err)
+			} else if !re.MatchString(output) {
+				check.Message = fmt.Sprintf("regex %q did not match output %q", expect.Contains, output)
+			} else {
+				check.Pass()
+			}
+			checks = append(checks, check)
+		}
+		if expect.NotContains != "" {
+			check := model.Check{Name: fmt.Sprintf("notContains %q", expect.NotContains), Kind: "notContains", Weight: weight}
+			re, err := regexp.Compile(expect.NotContains)
+			if err != nil {
+				check.Message = fmt.Sprintf("invalid regex %q in task spec: %v", expect.NotContains, err)
+			} else if re.MatchString(output) {
+				check.Message = fmt.Sprintf("regex %q matched output %q (should not have matched)", expect.NotContains, output)
+			} else {
+				check.Pass()
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks, setScores, verdicts, judgeErr
+}
+
+// truncate shortens s to at most n characters, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
+
+// failedChecks returns a failure for each check that did not pass, except informational checks
+// (weight 0), which do not decide the result.
+func failedChecks(checks []model.Check) []model.Failure {
+	var failures []model.Failure
+	for _, check := range checks {
+		if !check.Passed && check.Weight > 0 {
+			failures = append(failures, model.Failure{Message: check.Message})
+		}
+	}
+	return failures
+}
+
+// setAnswerTrimChars are stripped from extracted answers, so that e.g. "**resource-001**." counts as "resource-001".
+const setAnswerTrimChars = "*_`'\",.;:()[] "
+
+// scoreSet extracts answers from output with the expectation's line pattern and compares them to the expected set.
+func scoreSet(expect *SetExpectation, output string) (model.SetScore, error) {
+	score := model.SetScore{Pattern: expect.Pattern}
+
+	re, err := regexp.Compile(expect.Pattern)
+	if err != nil {
+		return score, fmt.Errorf("invalid regex %q: %w", expect.Pattern, err)
+	}
+
+	found := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		for _, match := range re.FindAllStringSubmatch(line, -1) {
+			answer := match[0]
+			if len(match) > 1 {
+				answer = match[1]
+			}
+			answer = strings.Trim(answer, setAnswerTrimChars)
+			if answer != "" {
+				found[answer] = true
+			}
+		}
+	}
+
+	expected := make(map[string]bool)
+	for _, answer := range expect.Expected {
+		expected[answer] = true
+	}
+
+	for answer := range found {
+		if expected[answer] {
+			score.TruePositives = append(score.TruePositives, answer)
+		} else {
+			score.FalsePositives = append(score.FalsePositives, answer)
+		}
+	}
+	for answer := range expected {
+		if !found[answer] {
+			score.FalseNegatives = append(score.FalseNegatives, answer)
+		}
+	}
+	sort.Strings(score.TruePositives)
+	sort.Strings(score.FalsePositives)
+	sort.Strings(score.FalseNegatives)
+
+	tp := float64(len(score.TruePositives))
+	score.Precision = 1
+	if n := tp + float64(len(score.FalsePositives)); n > 0 {
+		score.Precision = tp / n
+	}
+	score.Recall = 1
+	if n := tp + float64(len(score.FalseNegatives)); n > 0 {
+		score.Recall = tp / n
+	}
+	if score.Precision+score.Recall > 0 {
+		score.F1 = 2 * score.Precision * score.Recall / (score.Precision + score.Recall)
+	}
+	return score, nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster"
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/pool"
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+const (
+	// cleanupTimeout bounds the cleanup of a task (cleanup script, namespaces, isolated clusters),
+	// which also runs after the run was interrupted.
+	cleanupTimeout = 5 * time.Minute
+)
+
+// interruptContext returns a context that is cancelled on SIGINT or SIGTERM. Tasks in flight are then
+// stopped and cleaned up; a second signal exits immediately.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		// Restore the default behavior, so the next signal terminates the process.
+		stop()
+	}()
+	return ctx, stop
+}
+
+// recordCancellation marks the result as cancelled if the run was interrupted before the task completed.
+// Whatever failed after the interrupt (agent, verifiers) says nothing about the model.
+func recordCancellation(ctx context.Context, result *model.TaskResult) {
+	if ctx.Err() == nil || result.Result == model.ResultSkipped {
+		return
+	}
+	result.Cancel(fmt.Sprintf("run interrupted: %v", context.Cause(ctx)))
+}
+
+// cleanupWithTimeout runs the cleanup of the task execution, even if ctx was cancelled, but gives up
+// after cleanupTimeout so an interrupted run still exits.
+func (x *TaskExecution) cleanupWithTimeout(ctx context.Context) {
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+	if err := x.runCleanup(cleanupCtx); err != nil {
+		fmt.Printf("Warning: cleanup failed for task %s: %v\n", x.taskID, err)
+	}
+}
+
+// closeClusterPool deletes the clusters of the pool, even if ctx was cancelled, giving up after cleanupTimeout.
+// Closing the pool again has no effect.
+func closeClusterPool(ctx context.Context, clusterPool *pool.Pool) {
+	closeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+	if err := clusterPool.Close(closeCtx); err != nil {
+		fmt.Printf("Warning: deleting the cluster pool: %v\n", err)
+	}
+}
+
+// deleteCluster deletes the cluster, returning an error if that does not finish before ctx is done.
+// Providers do not take a context, so the deletion itself carries on in the background.
+func deleteCluster(ctx context.Context, provider cluster.Provider, name string) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- provider.Delete(name)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("deleting cluster %q did not finish in time and may need to be deleted manually: %w", name, ctx.Err())
+	}
+}
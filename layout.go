@@ -0,0 +1,98 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// indexFile lists every result in the output directory; it is rebuilt at the end of each run.
+const indexFile = "index.yaml"
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// pathSegment makes an ID (e.g. an LLM config ID containing a model such as "org/model") safe to use as a directory name.
+func pathSegment(id string) string {
+	return unsafePathChars.ReplaceAllString(id, "_")
+}
+
+// taskOutputDir is the directory for the results, log and trace of a run of the task:
+//
+//	<output-dir>/<task>/<llm config>[/variants/<variant>][/iterations/<n>]
+//
+// Variants other than the default and iterations are only part of the path when used.
+func (config *EvalConfig) taskOutputDir(taskID string, task *Task, llmConfig model.LLMConfig) string {
+	dir := filepath.Join(config.OutputDir, taskID, pathSegment(llmConfig.ID))
+	if task.variant != "" && task.variant != defaultVariant {
+		dir = filepath.Join(dir, "variants", task.variant)
+	}
+	if config.Iterations > 1 {
+		dir = filepath.Join(dir, "iterations", strconv.Itoa(task.iteration))
+	}
+	return dir
+}
+
+// ResultsIndex is the contents of the index file of an output directory.
+type ResultsIndex struct {
+	Results []IndexEntry `json:"results"`
+}
+
+// IndexEntry locates the results of one run.
+type IndexEntry struct {
+	Task      string `json:"task"`
+	Variant   string `json:"variant,omitempty"`
+	Iteration int    `json:"iteration,omitempty"`
+	LLMConfig string `json:"llmConfig"`
+	Result    string `json:"result"`
+
+	// Path is the results directory, relative to the output directory.
+	Path string `json:"path"`
+}
+
+// writeIndex writes the index of all results in outputDir, including those of earlier (resumed) runs.
+func writeIndex(outputDir string) error {
+	existing, err := loadExistingResults(outputDir)
+	if err != nil {
+		return err
+	}
+	var index ResultsIndex
+	for unit, r := range existing {
+		path, err := filepath.Rel(outputDir, r.dir)
+		if err != nil {
+			return err
+		}
+		index.Results = append(index.Results, IndexEntry{
+			Task:      unit.Task,
+			Variant:   unit.Variant,
+			Iteration: unit.Iteration,
+			LLMConfig: unit.LLMConfigID,
+			Result:    r.result.Result,
+			Path:      filepath.ToSlash(path),
+		})
+	}
+	sort.Slice(index.Results, func(i, j int) bool {
+		return index.Results[i].Path < index.Results[j].Path
+	})
+	if err := writeToYAMLFile(filepath.Join(outputDir, indexFile), index); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+	return nil
+}
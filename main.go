@@ -21,11 +21,19 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/gke-labs/k8s-ai-bench/pkg/agent"
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/pool"
+	"github.com/gke-labs/k8s-ai-bench/pkg/judge"
+	"github.com/gke-labs/k8s-ai-bench/pkg/llm"
 	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+	"github.com/gke-labs/k8s-ai-bench/pkg/preflight"
+	"github.com/gke-labs/k8s-ai-bench/pkg/usersim"
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
 	"sigs.k8s.io/yaml"
 )
 
@@ -45,13 +53,92 @@ type Task struct {
 	Disabled   bool   `json:"disabled,omitempty"`
 	Timeout    string `json:"timeout,omitempty"`
 
+	// Category is the primary user journey the task exercises (e.g. troubleshooting, policy).
+	Category string `json:"category,omitempty"`
+
+	// Tags are free-form labels (e.g. rbac, networking, storage) used to select and group tasks.
+	Tags []string `json:"tags,omitempty"`
+
+	// VerifierWeight is the relative weight of the verifier script in the task score (default 1).
+	VerifierWeight *float64 `json:"verifierWeight,omitempty"`
+
 	Expect []Expectation `json:"expect,omitempty"`
 
+	// Ready lists assertions that must hold after setup before the agent is started.
+	// If they do not hold within their timeouts the run is reported as a setup error.
+	Ready []verify.Assertion `json:"ready,omitempty"`
+
+	// Verify lists declarative assertions about the cluster state, evaluated after the agent finishes.
+	// They can be combined with (or replace) the Verifier script.
+	Verify []verify.Assertion `json:"verify,omitempty"`
+
 	Script []ScriptStep `json:"script,omitempty"`
 
-	// Isolation can be set to automatically create an isolated cluster
-	// TODO: support namespaces also
+	// Variants are paraphrases of the script prompts, run as separate variants of the task
+	// to measure whether success depends on the exact wording.
+	Variants []PromptVariant `json:"variants,omitempty"`
+
+	// Variables are sampled per run and rendered into prompts, expectations and
+	// verify assertions (as {{.NAME}}), and exported as environment variables to
+	// setup, verifier and cleanup scripts.
+	Variables map[string]TaskVariable `json:"variables,omitempty"`
+
+	// TurnIdleTimeout is how long the agent output must be quiet before we consider
+	// its turn finished and send the next script step (default 10s).
+	// Tasks that need it (see runsTurnByTurn) are run interactively even with --quiet.
+	TurnIdleTimeout string `json:"turnIdleTimeout,omitempty"`
+
+	// PromptMarker is an optional regex matching the agent's input prompt;
+	// when it appears in the output the turn is considered finished immediately.
+	PromptMarker string `json:"promptMarker,omitempty"`
+
+	// User describes the simulated user who answers the agent's clarifying questions.
+	// Conversing requires an interactive agent, so tasks with a user are always run turn by turn.
+	User *usersim.Spec `json:"user,omitempty"`
+
+	// Solution is an optional reference solution script; the selftest subcommand runs it to prove
+	// the task is solvable and the verifier accepts a correct solution.
+	Solution string `json:"solution,omitempty"`
+
+	// Requires lists prerequisites the cluster must meet; if they are not met the task is skipped.
+	Requires *preflight.Requirements `json:"requires,omitempty"`
+
+	// Isolation can be set to automatically create an isolated cluster or namespace
 	Isolation IsolationMode `json:"isolation,omitempty"`
+
+	// variant is the ID of the prompt variant this copy of the task runs (see WithVariant).
+	variant string
+
+	// iteration is the (1-based) repetition this copy of the task runs, with --iterations.
+	iteration int
+}
+
+// runsTurnByTurn reports whether the task must be run turn by turn, even in quiet mode: it has several
+// script steps, checks of its own in a script step, or a simulated user.
+func (t *Task) runsTurnByTurn() bool {
+	if len(t.Script) > 1 || t.User != nil {
+		return true
+	}
+	for _, step := range t.Script {
+		if len(step.Expect) > 0 || step.Verifier != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Labels returns the task category and tags, which are what --tags and --exclude-tags match against.
+func (t *Task) Labels() []string {
+	var labels []string
+	if t.Category != "" {
+		labels = append(labels, t.Category)
+	}
+	for _, tag := range t.Tags {
+		if !slices.Contains(labels, tag) {
+			labels = append(labels, tag)
+		}
+	}
+	return labels
 }
 
 type IsolationMode string
@@ -59,11 +146,24 @@ type IsolationMode string
 const (
 	// IsolationModeCluster will create a cluster for the task evaluation.
 	IsolationModeCluster IsolationMode = "cluster"
+
+	// IsolationModeNamespace will create a uniquely named namespace for the task evaluation,
+	// exposed as TASK_NAMESPACE, and give the agent a kubeconfig that only has rights in it.
+	IsolationModeNamespace IsolationMode = "namespace"
 )
 
 type ScriptStep struct {
 	Prompt     string `json:"prompt"`
 	PromptFile string `json:"promptFile"`
+
+	// Expect is evaluated against the agent output for this step's turn.
+	Expect []Expectation `json:"expect,omitempty"`
+
+	// Verifier is an optional script run against the cluster right after this step's turn.
+	Verifier string `json:"verifier,omitempty"`
+
+	// VerifierWeight is the relative weight of the step verifier in the task score (default 1).
+	VerifierWeight *float64 `json:"verifierWeight,omitempty"`
 }
 
 // ResolvePrompt resolves the prompt from either inline or file source
@@ -101,29 +201,112 @@ func (s *ScriptStep) ResolvePrompt(baseDir string) (string, error) {
 type Expectation struct {
 	Contains    string `json:"contains,omitempty"`
 	NotContains string `json:"notContains,omitempty"`
+
+	// Set extracts a set of answers from the output and scores it against an expected set.
+	Set *SetExpectation `json:"set,omitempty"`
+
+	// Judge has a judge model grade the output against a rubric.
+	Judge *JudgeExpectation `json:"judge,omitempty"`
+
+	// Weight is the relative weight of this expectation in the task score (default 1);
+	// an expectation with weight 0 is informational and does not decide the result.
+	Weight *float64 `json:"weight,omitempty"`
 }
 
-type EvalConfig struct {
-	LLMConfigs                   []model.LLMConfig
-	KubeConfig                   string
-	TasksDir                     string
-	TaskPattern                  string
-	AgentBin                     string
-	Concurrency                  int
-	ClusterCreationPolicy        ClusterCreationPolicy
-	ClusterProvider              string
-	HostClusterContext           string
-	HostClusterKubeConfig        string
-	HostClusterIngressExternalIP string
+// SetExpectation scores answers such as "VIOLATING: <name>" lines with precision and recall.
+// The expectation is met only when the extracted set equals the expected set.
+type SetExpectation struct {
+	// Pattern is a regex matched against each output line; the first capture group
+	// (or the whole match, if there is no group) is an answer.
+	// Surrounding markdown emphasis and punctuation is stripped from answers.
+	Pattern string `json:"pattern"`
+
+	// Expected is the set of answers that should be reported.
+	Expected []string `json:"expected"`
+}
 
-	OutputDir string
+// JudgeExpectation grades free-form answers (e.g. explanations of why a pod is pending) with a judge model.
+type JudgeExpectation struct {
+	// Rubric describes what a good answer must contain.
+	Rubric string `json:"rubric"`
+
+	// Reference is an optional example of a correct answer.
+	Reference string `json:"reference,omitempty"`
+
+	// PassScore is the judge score (0..1) from which the expectation is met (default 0.7).
+	PassScore float64 `json:"passScore,omitempty"`
+}
+
+// defaultJudgePassScore is the default JudgeExpectation.PassScore.
+const defaultJudgePassScore = 0.7
+
+type EvalConfig struct {
+	LLMConfigs                   []model.LLMConfig     `json:"llmConfigs,omitempty"`
+	KubeConfig                   string                `json:"kubeConfig,omitempty"`
+	TasksDir                     string                `json:"tasksDir,omitempty"`
+	TaskPattern                  string                `json:"taskPattern,omitempty"`
+	Difficulties                 []string              `json:"difficulties,omitempty"`
+	Tags                         []string              `json:"tags,omitempty"`
+	ExcludeTags                  []string              `json:"excludeTags,omitempty"`
+	AgentBin                     string                `json:"agentBin,omitempty"`
+	Concurrency                  int                   `json:"concurrency,omitempty"`
+	ClusterCreationPolicy        ClusterCreationPolicy `json:"clusterCreationPolicy,omitempty"`
+	ClusterProvider              string                `json:"clusterProvider,omitempty"`
+	HostClusterContext           string                `json:"hostClusterContext,omitempty"`
+	HostClusterKubeConfig        string                `json:"hostClusterKubeConfig,omitempty"`
+	HostClusterIngressExternalIP string                `json:"hostClusterIngressExternalIP,omitempty"`
+
+	// ClusterPoolSize is the number of clusters kept warm for tasks with cluster isolation
+	// (0 = create and delete a cluster for every execution); ClusterPoolReset is how a returned cluster is reset.
+	ClusterPoolSize  int                `json:"clusterPoolSize,omitempty"`
+	ClusterPoolReset pool.ResetStrategy `json:"clusterPoolReset,omitempty"`
+
+	// MaxConcurrencyPerModel caps the concurrent runs of each model (0 = no cap beyond Concurrency);
+	// ModelConcurrency overrides it for specific models, e.g. to respect a provider's rate limits.
+	MaxConcurrencyPerModel int            `json:"maxConcurrencyPerModel,omitempty"`
+	ModelConcurrency       map[string]int `json:"modelConcurrency,omitempty"`
+
+	// Seed drives the sampling of task variables, so a run can be reproduced exactly.
+	Seed int64 `json:"seed,omitempty"`
+
+	// UserSimulator is the LLM provider that answers clarifying questions not covered by a task's
+	// canned responses, or "stub" to answer locally from the task facts.
+	UserSimulator      string `json:"userSimulator,omitempty"`
+	UserSimulatorModel string `json:"userSimulatorModel,omitempty"`
+
+	// Judge is the LLM provider that grades judge expectations, or "stub" for a local word-overlap judge.
+	Judge      string `json:"judge,omitempty"`
+	JudgeModel string `json:"judgeModel,omitempty"`
+
+	// Resume skips the runs that already have results in OutputDir.
+	Resume bool `json:"resume,omitempty"`
+
+	// Rerun re-executes the runs in OutputDir whose result is one of these states (e.g. "error"),
+	// keeping the previous attempts.
+	Rerun []string `json:"rerun,omitempty"`
+
+	// Iterations is the number of times each task is run for each model (default 1).
+	Iterations int `json:"iterations,omitempty"`
+
+	// PromptVariants selects the prompt variants to run per task: "all", "default" (the default
+	// when empty) or the number of variants to sample.
+	PromptVariants string `json:"promptVariants,omitempty"`
+
+	OutputDir string `json:"outputDir,omitempty"`
+
+	// clusterPool leases the clusters of tasks with cluster isolation, if ClusterPoolSize is set.
+	clusterPool *pool.Pool
 }
 
 type AnalyzeConfig struct {
 	InputDir          string
+	TasksDir          string
 	OutputFormat      string
 	IgnoreToolUseShim bool
 	ShowFailures      bool
+
+	// PassK is the k of pass@k and pass^k; 0 uses the smallest number of runs of any task.
+	PassK int
 }
 
 func expandPath(path string) (string, error) {
@@ -144,8 +327,13 @@ func main() {
 		return
 	}
 
-	ctx := context.Background()
-	if err := run(ctx); err != nil {
+	ctx, stop := interruptContext()
+	err := run(ctx)
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("interrupted")
+	}
+	stop()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
 		os.Exit(1)
 	}
@@ -156,7 +344,9 @@ func printUsage() {
 	fmt.Fprintf(os.Stderr, "Usage: %s <command> [options]\n\n", os.Args[0])
 	fmt.Fprintf(os.Stderr, "Commands:\n")
 	fmt.Fprintf(os.Stderr, "  run       Run evaluation benchmarks\n")
-	fmt.Fprintf(os.Stderr, "  analyze   Analyze results from previous benchmark runs\n\n")
+	fmt.Fprintf(os.Stderr, "  analyze   Analyze results from previous benchmark runs\n")
+	fmt.Fprintf(os.Stderr, "  validate  Lint task directories\n")
+	fmt.Fprintf(os.Stderr, "  selftest  Check tasks against their reference solutions\n\n")
 	fmt.Fprintf(os.Stderr, "Run '%s <command> --help' for more information on a command.\n", os.Args[0])
 }
 
@@ -187,9 +377,13 @@ func run(ctx context.Context) error {
 		return runEvals(ctx)
 	case "analyze":
 		return runAnalyze()
+	case "validate":
+		return runValidate()
+	case "selftest":
+		return runSelfTest(ctx)
 	default:
 		printUsage()
-		return fmt.Errorf("unknown subcommand: %s, valid options are 'run' or 'analyze'", subCommand)
+		return fmt.Errorf("unknown subcommand: %s, valid options are 'run', 'analyze', 'validate' or 'selftest'", subCommand)
 	}
 }
 
@@ -209,32 +403,176 @@ func runEvals(ctx context.Context) error {
 
 	llmProvider := "gemini"
 	modelList := ""
-	defaultKubeConfig := "~/.kube/config"
 	enableToolUseShim := false
 	quiet := true
 	mcpClient := false
-	clusterProvider := "kind"
-	hostClusterContext := ""
-	hostClusterIngressExternalIP := ""
-
+	difficulties := ""
+	tags := ""
+	excludeTags := ""
+	rerun := ""
+	modelConcurrency := ""
+	agentConfigPath := ""
+	runConfigPath := ""
+
+	flag.StringVar(&runConfigPath, "config", runConfigPath, "YAML file declaring the models, the matrix of agent features to run them with and the settings of the run; flags override its fields")
+	addClusterFlags(&config)
 	flag.StringVar(&config.TasksDir, "tasks-dir", config.TasksDir, "Directory containing evaluation tasks")
-	flag.StringVar(&config.KubeConfig, "kubeconfig", config.KubeConfig, "Path to kubeconfig file")
 	flag.StringVar(&config.TaskPattern, "task-pattern", config.TaskPattern, "Pattern to filter tasks (e.g. 'pod' or 'redis')")
+	flag.StringVar(&difficulties, "difficulty", difficulties, "Comma-separated list of difficulties to run (e.g. 'easy,medium')")
+	flag.StringVar(&tags, "tags", tags, "Comma-separated list of tags; only tasks with at least one of them (as tag or category) are run")
+	flag.StringVar(&excludeTags, "exclude-tags", excludeTags, "Comma-separated list of tags; tasks with any of them (as tag or category) are skipped")
 	flag.StringVar(&config.AgentBin, "agent-bin", config.AgentBin, "Path to kubernetes agent binary")
+	flag.StringVar(&agentConfigPath, "agent-config", agentConfigPath, "YAML file selecting the agent to benchmark: kubectl-ai (the default, run from --agent-bin), a command template or an HTTP endpoint")
 	flag.StringVar(&llmProvider, "llm-provider", llmProvider, "Specific LLM provider to evaluate (e.g. 'gemini' or 'ollama')")
 	flag.StringVar(&modelList, "models", modelList, "Comma-separated list of models to evaluate (e.g. 'gemini-1.0,gemini-2.0')")
 	flag.BoolVar(&enableToolUseShim, "enable-tool-use-shim", enableToolUseShim, "Enable tool use shim")
-	flag.BoolVar(&quiet, "quiet", quiet, "Quiet mode (non-interactive mode)")
-	flag.IntVar(&config.Concurrency, "concurrency", 0, "Number of tasks to run concurrently (0 = auto, 1 = sequential)")
-	flag.StringVar((*string)(&config.ClusterCreationPolicy), "cluster-creation-policy", string(CreateIfNotExist), "Cluster creation policy: AlwaysCreate, CreateIfNotExist, DoNotCreate")
+	flag.BoolVar(&quiet, "quiet", quiet, "Quiet mode (non-interactive mode) for tasks with a single prompt; tasks with several script steps, step checks or a simulated user always run turn by turn")
 	flag.StringVar(&config.OutputDir, "output-dir", config.OutputDir, "Directory to write results to")
 	flag.BoolVar(&mcpClient, "mcp-client", mcpClient, "Enable MCP client in kubectl-ai")
-	flag.StringVar(&config.ClusterProvider, "cluster-provider", clusterProvider, "Cluster provider to use (kind or vcluster)")
-	flag.StringVar(&config.HostClusterContext, "host-cluster-context", hostClusterContext, "Host cluster context for vcluster (optional)")
-	flag.StringVar(&config.HostClusterKubeConfig, "host-cluster-kubeconfig", "", "Host cluster kubeconfig for vcluster (optional, defaults to --kubeconfig)")
-	flag.StringVar(&config.HostClusterIngressExternalIP, "host-cluster-ingress-external-ip", hostClusterIngressExternalIP, "Host cluster ingress external IP for vcluster (optional)")
+	flag.Int64Var(&config.Seed, "seed", 0, "Seed for sampling task variables (0 = random)")
+	flag.StringVar(&config.UserSimulator, "user-simulator", "stub", "LLM provider that plays the user answering clarifying questions, or 'stub' to answer from the task facts locally")
+	flag.StringVar(&config.UserSimulatorModel, "user-simulator-model", "gemini-2.5-flash", "Model used by the user simulator")
+	flag.StringVar(&config.Judge, "judge", judge.Stub, "LLM provider that grades judge expectations, or 'stub' for a deterministic local judge")
+	flag.StringVar(&config.JudgeModel, "judge-model", "gemini-2.5-flash", "Model used to grade judge expectations")
+	flag.BoolVar(&config.Resume, "resume", false, "Only run the tasks that have no results in the output directory yet")
+	flag.StringVar(&rerun, "rerun", rerun, "Comma-separated result states (e.g. 'error,fail') to re-run from the output directory; previous attempts are kept")
+	flag.IntVar(&config.Iterations, "iterations", 1, "Number of times to run each task for each model")
+	flag.StringVar(&modelConcurrency, "model-concurrency", modelConcurrency, "Maximum concurrent runs per model: a limit for every model and/or per-model limits (e.g. '2' or '2,gemini-2.5-pro=1')")
+	flag.StringVar(&config.PromptVariants, "prompt-variants", PromptVariantsDefault, "Prompt variants to run per task: 'default', 'all' or the number of variants to sample")
 	flag.Parse()
 
+	config.Difficulties = splitList(difficulties)
+	config.Tags = splitList(tags)
+	config.ExcludeTags = splitList(excludeTags)
+	config.Rerun = splitList(rerun)
+
+	perModelLimit, modelLimits, err := parseModelConcurrency(modelConcurrency)
+	if err != nil {
+		return err
+	}
+	config.MaxConcurrencyPerModel, config.ModelConcurrency = perModelLimit, modelLimits
+
+	matrix := llmMatrix{
+		MatrixConfig: MatrixConfig{
+			ToolUseShim: []bool{enableToolUseShim},
+			MCPClient:   []bool{mcpClient},
+			Agents:      []model.AgentConfig{{Type: agent.TypeKubectlAI}},
+		},
+		providers: []ProviderConfig{{ID: "gemini", Models: []ModelConfig{{ID: "gemini-2.5-pro"}}}},
+		quiet:     quiet,
+	}
+	if modelList != "" {
+		if llmProvider == "" {
+			return fmt.Errorf("--llm-provider is required when --models is specified")
+		}
+		provider := ProviderConfig{ID: llmProvider}
+		for _, modelID := range splitList(modelList) {
+			provider.Models = append(provider.Models, ModelConfig{ID: modelID})
+		}
+		matrix.providers = []ProviderConfig{provider}
+	}
+	if agentConfigPath != "" {
+		agentConfig, err := loadAgentConfig(agentConfigPath)
+		if err != nil {
+			return err
+		}
+		matrix.Agents = []model.AgentConfig{agentConfig}
+	}
+
+	if runConfigPath != "" {
+		runConfig, err := loadRunConfig(runConfigPath)
+		if err != nil {
+			return err
+		}
+		set := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+		if err := runConfig.apply(&config, &matrix, set); err != nil {
+			return err
+		}
+	}
+
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Using seed %d\n", config.Seed)
+
+	if _, err := (&Task{}).selectVariants(config.PromptVariants, config.Seed, ""); err != nil {
+		return err
+	}
+
+	if config.UserSimulator != "stub" && !slices.Contains(llm.Providers, config.UserSimulator) {
+		return fmt.Errorf("invalid --user-simulator %q, valid options are stub, %s", config.UserSimulator, strings.Join(llm.Providers, ", "))
+	}
+
+	for _, state := range config.Rerun {
+		if !slices.Contains(resultStates, state) {
+			return fmt.Errorf("invalid --rerun state %q, valid options are %s", state, strings.Join(resultStates, ", "))
+		}
+	}
+
+	if config.Iterations < 1 {
+		return fmt.Errorf("--iterations must be at least 1")
+	}
+
+	if !slices.Contains(judge.Providers(), config.Judge) {
+		return fmt.Errorf("invalid --judge %q, valid options are %s", config.Judge, strings.Join(judge.Providers(), ", "))
+	}
+
+	for _, d := range config.Difficulties {
+		if !slices.Contains(allowedDifficulties, d) {
+			return fmt.Errorf("invalid difficulty %q, valid options are %s", d, strings.Join(allowedDifficulties, ", "))
+		}
+	}
+
+	if err := resolveClusterConfig(&config); err != nil {
+		return err
+	}
+
+	if config.LLMConfigs, err = matrix.expand(config.AgentBin); err != nil {
+		return err
+	}
+	for _, llmConfig := range config.LLMConfigs {
+		if err := agent.Validate(llmConfig, config.AgentBin); err != nil {
+			return fmt.Errorf("invalid agent for %s: %w", llmConfig.ID, err)
+		}
+	}
+
+	tasks, err := loadTasks(config)
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	// If concurrency is set to auto (0), use the number of tasks
+	if config.Concurrency == 0 {
+		config.Concurrency = len(tasks)
+		fmt.Printf("Auto-configuring concurrency to %d (number of tasks)\n", config.Concurrency)
+	}
+
+	if _, err := runEvaluation(ctx, config, evaluateTask); err != nil {
+		return fmt.Errorf("running evaluation: %w", err)
+	}
+
+	fmt.Printf("Total evaluation time: %s\n", time.Since(start))
+	return nil
+}
+
+// addClusterFlags registers the flags shared by subcommands that run tasks against clusters.
+func addClusterFlags(config *EvalConfig) {
+	flag.StringVar(&config.KubeConfig, "kubeconfig", config.KubeConfig, "Path to kubeconfig file")
+	flag.IntVar(&config.Concurrency, "concurrency", 0, "Number of runs (task and model pairs) to execute concurrently (0 = auto, 1 = sequential)")
+	flag.StringVar((*string)(&config.ClusterCreationPolicy), "cluster-creation-policy", string(CreateIfNotExist), "Cluster creation policy: AlwaysCreate, CreateIfNotExist, DoNotCreate")
+	flag.StringVar(&config.ClusterProvider, "cluster-provider", "kind", "Cluster provider to use (kind or vcluster)")
+	flag.StringVar(&config.HostClusterContext, "host-cluster-context", "", "Host cluster context for vcluster (optional)")
+	flag.StringVar(&config.HostClusterKubeConfig, "host-cluster-kubeconfig", "", "Host cluster kubeconfig for vcluster (optional, defaults to --kubeconfig)")
+	flag.StringVar(&config.HostClusterIngressExternalIP, "host-cluster-ingress-external-ip", "", "Host cluster ingress external IP for vcluster (optional)")
+	flag.IntVar(&config.ClusterPoolSize, "cluster-pool-size", 0, "Number of clusters to keep warm for tasks with cluster isolation (0 = create a cluster per execution)")
+	flag.StringVar((*string)(&config.ClusterPoolReset), "cluster-pool-reset", string(pool.ResetClean), "How pooled clusters are reset between tasks: clean (delete what the task created) or recreate")
+}
+
+// resolveClusterConfig applies provider defaults and expands the kubeconfig paths after flag parsing.
+func resolveClusterConfig(config *EvalConfig) error {
+	defaultKubeConfig := "~/.kube/config"
+
 	if config.ClusterProvider == "vcluster" {
 		if config.HostClusterContext == "" {
 			return fmt.Errorf("--host-cluster-context is required when using --cluster-provider=vcluster")
@@ -243,6 +581,13 @@ func runEvals(ctx context.Context) error {
 		config.ClusterCreationPolicy = DoNotCreate
 	}
 
+	if config.ClusterPoolSize < 0 {
+		return fmt.Errorf("--cluster-pool-size must not be negative")
+	}
+	if !slices.Contains(pool.ResetStrategies, config.ClusterPoolReset) {
+		return fmt.Errorf("invalid --cluster-pool-reset %q, valid options are %s and %s", config.ClusterPoolReset, pool.ResetClean, pool.ResetRecreate)
+	}
+
 	if config.KubeConfig == "" {
 		config.KubeConfig = defaultKubeConfig
 	}
@@ -262,59 +607,35 @@ func runEvals(ctx context.Context) error {
 		}
 		config.HostClusterKubeConfig = expandedHostKubeconfig
 	}
+	return nil
+}
 
-	defaultModels := map[string][]string{
-		"gemini": {"gemini-2.5-pro"},
-	}
-
-	models := defaultModels
-	if modelList != "" {
-		if llmProvider == "" {
-			return fmt.Errorf("--llm-provider is required when --models is specified")
-		}
-		modelSlice := strings.Split(modelList, ",")
-		models = map[string][]string{
-			llmProvider: modelSlice,
-		}
-	}
-
-	for llmProviderID, models := range models {
-		var toolUseShimStr string
-		if enableToolUseShim {
-			toolUseShimStr = "shim_enabled"
-		} else {
-			toolUseShimStr = "shim_disabled"
-		}
-		for _, modelID := range models {
-			id := fmt.Sprintf("%s-%s-%s", toolUseShimStr, llmProviderID, modelID)
-			config.LLMConfigs = append(config.LLMConfigs, model.LLMConfig{
-				ID:                id,
-				ProviderID:        llmProviderID,
-				ModelID:           modelID,
-				EnableToolUseShim: enableToolUseShim,
-				Quiet:             quiet,
-				McpClient:         mcpClient,
-			})
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
 		}
 	}
+	return out
+}
 
-	tasks, err := loadTasks(config)
+// loadAgentConfig reads the --agent-config file. Unknown fields are rejected, so a typo does not
+// silently benchmark the wrong agent.
+func loadAgentConfig(path string) (model.AgentConfig, error) {
+	var agentConfig model.AgentConfig
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return fmt.Errorf("failed to load tasks: %w", err)
+		return agentConfig, fmt.Errorf("reading agent config: %w", err)
 	}
-
-	// If concurrency is set to auto (0), use the number of tasks
-	if config.Concurrency == 0 {
-		config.Concurrency = len(tasks)
-		fmt.Printf("Auto-configuring concurrency to %d (number of tasks)\n", config.Concurrency)
+	if err := yaml.UnmarshalStrict(data, &agentConfig); err != nil {
+		return agentConfig, fmt.Errorf("parsing agent config %q: %w", path, err)
 	}
-
-	if err := runEvaluation(ctx, config); err != nil {
-		return fmt.Errorf("running evaluation: %w", err)
+	if agentConfig.Type == "" {
+		agentConfig.Type = agent.TypeKubectlAI
 	}
-
-	fmt.Printf("Total evaluation time: %s\n", time.Since(start))
-	return nil
+	return agentConfig, nil
 }
 
 func runAnalyze() error {
@@ -333,10 +654,12 @@ func runAnalyze() error {
 
 	var resultsFilePath string
 	flag.StringVar(&config.InputDir, "input-dir", config.InputDir, "Directory containing evaluation results (required)")
+	flag.StringVar(&config.TasksDir, "tasks-dir", config.TasksDir, "Directory containing the task definitions; enables the breakdown by difficulty and tag")
 	flag.StringVar(&config.OutputFormat, "output-format", config.OutputFormat, "Output format (markdown or json)")
 	flag.BoolVar(&config.IgnoreToolUseShim, "ignore-tool-use-shim", true, "Ignore tool use shim")
 	flag.BoolVar(&config.ShowFailures, "show-failures", false, "Show failure details in markdown output")
 	flag.StringVar(&resultsFilePath, "results-filepath", "", "Optional file path to write results to")
+	flag.IntVar(&config.PassK, "pass-k", 0, "k for pass@k and pass^k over repeated runs (0 = fewest runs of any task)")
 	flag.Parse()
 
 	// Check if input-dir is provided
@@ -360,10 +683,18 @@ func runAnalyze() error {
 		return fmt.Errorf("collecting results: %w", err)
 	}
 
+	manifests, err := collectManifests(config.InputDir)
+	if err != nil {
+		return fmt.Errorf("collecting run manifests: %w", err)
+	}
+	for _, warning := range taskHashMismatches(manifests) {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+	}
+
 	// Format and output results
 	switch config.OutputFormat {
 	case "markdown":
-		if err := printMarkdownResults(config, allResults, resultsFilePath); err != nil {
+		if err := printMarkdownResults(config, allResults, manifests, resultsFilePath); err != nil {
 			return fmt.Errorf("printing markdown results: %w", err)
 		}
 	case "json":
@@ -382,12 +713,19 @@ func runAnalyze() error {
 func collectResults(inputDir string) ([]model.TaskResult, error) {
 	var allResults []model.TaskResult
 
-	// Walk through the directory structure to find all results.yaml files
+	// Walk through the directory structure to find all results.yaml files. This reads both the
+	// current layout (<task>/<llm config>/..., see taskOutputDir) and the older <task>/results.yaml
+	// layout, as well as runs that were interrupted before their index was written.
 	err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
+		// Earlier attempts of re-run tasks are superseded by the latest results
+		if info.IsDir() && info.Name() == attemptsDir {
+			return filepath.SkipDir
+		}
+
 		// Only process results.yaml files
 		if !info.IsDir() && info.Name() == "results.yaml" {
 			// Read and parse the results file
@@ -424,9 +762,9 @@ func printFailureAndErrorDetails(buffer *strings.Builder, results []model.TaskRe
 			}
 			// for the IgnoreToolUseShim case
 			if showModel {
-				buffer.WriteString(fmt.Sprintf("**Task: %s (%s)**\n", result.Task, result.LLMConfig.ModelID))
+				buffer.WriteString(fmt.Sprintf("**Task: %s (%s)**\n", resultTaskName(result), result.LLMConfig.ModelID))
 			} else {
-				buffer.WriteString(fmt.Sprintf("**Task: %s**\n", result.Task))
+				buffer.WriteString(fmt.Sprintf("**Task: %s**\n", resultTaskName(result)))
 			}
 			buffer.WriteString(fmt.Sprintf("**Result: %s**\n", result.Result))
 			for _, failure := range result.Failures {
@@ -440,12 +778,29 @@ func printFailureAndErrorDetails(buffer *strings.Builder, results []model.TaskRe
 	}
 }
 
-func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resultsFilePath string) error {
+func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, manifests []RunManifest, resultsFilePath string) error {
 	// Create a buffer to hold the output
 	var buffer strings.Builder
 
 	buffer.WriteString("# k8s-ai-bench Evaluation Results\n\n")
 
+	// --- Provenance of the results ---
+	printRuns(&buffer, manifests)
+
+	// Skipped tasks were never evaluated, and cancelled tasks were interrupted, so they are reported
+	// separately rather than counted as errors.
+	var skipped, cancelled, evaluated []model.TaskResult
+	for _, result := range results {
+		if result.Result == model.ResultSkipped {
+			skipped = append(skipped, result)
+		} else if result.Result == model.ResultCancelled {
+			cancelled = append(cancelled, result)
+		} else {
+			evaluated = append(evaluated, result)
+		}
+	}
+	results = evaluated
+
 	allModels := make(map[string]bool) // Track all unique models
 	for _, result := range results {
 		allModels[result.LLMConfig.ModelID] = true
@@ -477,15 +832,17 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 
 	if config.IgnoreToolUseShim {
 		// Simplified table ignoring shim status
-		buffer.WriteString("| Model | Success | Fail | Error |\n")
-		buffer.WriteString("|-------|---------|------|-------|\n")
+		buffer.WriteString("| Model | Success | Fail | Error | Mean Score |\n")
+		buffer.WriteString("|-------|---------|------|-------|------------|\n")
 
-		for _, model := range models {
+		for _, modelID := range models {
 			successCount := 0
 			failCount := 0
 			errorCount := 0
+			var modelResults []model.TaskResult
 			for _, result := range results {
-				if result.LLMConfig.ModelID == model {
+				if result.LLMConfig.ModelID == modelID {
+					modelResults = append(modelResults, result)
 					if strings.Contains(strings.ToLower(result.Result), "success") {
 						successCount++
 					} else if strings.Contains(strings.ToLower(result.Result), "fail") {
@@ -495,11 +852,11 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 					}
 				}
 			}
-			buffer.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n", model, successCount, failCount, errorCount))
+			buffer.WriteString(fmt.Sprintf("| %s | %d | %d | %d | %.2f |\n", modelID, successCount, failCount, errorCount, meanScore(modelResults)))
 		}
 		// Overall totals row
 		buffer.WriteString("| **Total** |")
-		buffer.WriteString(fmt.Sprintf(" %d | %d | %d |\n\n", overallSuccessCount, overallFailCount, overallErrorCount))
+		buffer.WriteString(fmt.Sprintf(" %d | %d | %d | %.2f |\n\n", overallSuccessCount, overallFailCount, overallErrorCount, meanScore(results)))
 
 	} else {
 		// Original table grouped by tool use shim status
@@ -574,7 +931,77 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 	buffer.WriteString(fmt.Sprintf("- Total Runs: %d\n", totalCount))
 	buffer.WriteString(fmt.Sprintf("- Overall Success: %d (%d%%)\n", overallSuccessCount, calculatePercentage(overallSuccessCount, totalCount)))
 	buffer.WriteString(fmt.Sprintf("- Overall Fail: %d (%d%%)\n", overallFailCount, calculatePercentage(overallFailCount, totalCount)))
-	buffer.WriteString(fmt.Sprintf("- Overall Error: %d (%d%%)\n\n", overallErrorCount, calculatePercentage(overallErrorCount, totalCount)))
+	buffer.WriteString(fmt.Sprintf("- Overall Error: %d (%d%%)\n", overallErrorCount, calculatePercentage(overallErrorCount, totalCount)))
+	buffer.WriteString(fmt.Sprintf("- Skipped: %d\n", len(skipped)))
+	if len(cancelled) > 0 {
+		buffer.WriteString(fmt.Sprintf("- Cancelled: %d (re-run them with --resume)\n", len(cancelled)))
+	}
+	buffer.WriteString("\n")
+
+	if len(skipped) > 0 {
+		sort.Slice(skipped, func(i, j int) bool {
+			if skipped[i].Task != skipped[j].Task {
+				return skipped[i].Task < skipped[j].Task
+			}
+			return skipped[i].LLMConfig.ModelID < skipped[j].LLMConfig.ModelID
+		})
+		buffer.WriteString("## Skipped Tasks\n\n")
+		buffer.WriteString("| Task | Model | Reason |\n")
+		buffer.WriteString("|------|-------|--------|\n")
+		for _, result := range skipped {
+			buffer.WriteString(fmt.Sprintf("| %s | %s | %s |\n", resultTaskName(result), result.LLMConfig.ModelID, result.SkipReason))
+		}
+		buffer.WriteString("\n")
+	}
+
+	// --- Breakdown by task metadata ---
+	// Results record the metadata of their task; older ones are looked up in --tasks-dir.
+	if config.TasksDir != "" || slices.ContainsFunc(results, func(r model.TaskResult) bool { return r.Difficulty != "" }) {
+		var tasks map[string]Task
+		if config.TasksDir != "" {
+			var err error
+			tasks, err = loadTaskDefinitions(config.TasksDir)
+			if err != nil {
+				return fmt.Errorf("loading task definitions: %w", err)
+			}
+		}
+		byDifficulty := make(map[string][]model.TaskResult)
+		byTag := make(map[string][]model.TaskResult)
+		for _, result := range results {
+			task := Task{Difficulty: result.Difficulty, Category: result.Category, Tags: result.Tags}
+			if result.Difficulty == "" {
+				var ok bool
+				if task, ok = tasks[result.Task]; !ok {
+					byDifficulty["unknown"] = append(byDifficulty["unknown"], result)
+					byTag["unknown"] = append(byTag["unknown"], result)
+					continue
+				}
+			}
+			difficulty := task.Difficulty
+			if difficulty == "" {
+				difficulty = "unspecified"
+			}
+			byDifficulty[difficulty] = append(byDifficulty[difficulty], result)
+			labels := task.Labels()
+			if len(labels) == 0 {
+				labels = []string{"untagged"}
+			}
+			for _, label := range labels {
+				byTag[label] = append(byTag[label], result)
+			}
+		}
+		printBreakdown(&buffer, "Difficulty", byDifficulty, models)
+		printBreakdown(&buffer, "Tag", byTag, models)
+	}
+
+	// --- Consistency across prompt variants ---
+	printVariantConsistency(&buffer, results)
+
+	// --- Repeated runs (--iterations) ---
+	printRepeatedRuns(&buffer, results, config.PassK)
+
+	// --- Agent behavior, from the traces ---
+	printAgentMetrics(&buffer, results)
 
 	// --- Detailed Results ---
 	if config.IgnoreToolUseShim {
@@ -586,8 +1013,8 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 
 		for _, model := range models {
 			buffer.WriteString(fmt.Sprintf("## Model: %s\n\n", model))
-			buffer.WriteString("| Task | Provider | Result |\n")
-			buffer.WriteString("|------|----------|--------|\n")
+			buffer.WriteString("| Task | Provider | Result | Score |\n")
+			buffer.WriteString("|------|----------|--------|-------|\n")
 
 			modelSuccessCount := 0
 			modelFailCount := 0
@@ -611,10 +1038,10 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 					modelErrorCount++
 				}
 
-				buffer.WriteString(fmt.Sprintf("| %s | %s | %s %s |\n",
-					result.Task,
+				buffer.WriteString(fmt.Sprintf("| %s | %s | %s %s%s | %.2f |\n",
+					resultTaskName(result),
 					result.LLMConfig.ProviderID,
-					resultEmoji, result.Result))
+					resultEmoji, result.Result, formatSetScore(result), resultScore(result)))
 			}
 
 			// Add summary for this model
@@ -622,7 +1049,13 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 			buffer.WriteString(fmt.Sprintf("- Total: %d\n", modelTotalCount))
 			buffer.WriteString(fmt.Sprintf("- Success: %d (%d%%)\n", modelSuccessCount, calculatePercentage(modelSuccessCount, modelTotalCount)))
 			buffer.WriteString(fmt.Sprintf("- Fail: %d (%d%%)\n", modelFailCount, calculatePercentage(modelFailCount, modelTotalCount)))
-			buffer.WriteString(fmt.Sprintf("- Error: %d (%d%%)\n\n", modelErrorCount, calculatePercentage(modelErrorCount, modelTotalCount)))
+			buffer.WriteString(fmt.Sprintf("- Error: %d (%d%%)\n", modelErrorCount, calculatePercentage(modelErrorCount, modelTotalCount)))
+			buffer.WriteString(fmt.Sprintf("- Mean Score: %.2f\n", meanScore(modelResults)))
+			if meanF1, n := meanSetF1(modelResults); n > 0 {
+				buffer.WriteString(fmt.Sprintf("- Mean F1: %.2f (%d runs with set expectations)\n", meanF1, n))
+			}
+			printClarificationRounds(&buffer, modelResults)
+			buffer.WriteString("\n")
 			// After the summary, print failure details
 			if config.ShowFailures {
 				printFailureAndErrorDetails(&buffer, modelResults, model, false)
@@ -653,8 +1086,8 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 			buffer.WriteString(fmt.Sprintf("## Tool Use: %s\n\n", toolUseShimStr))
 
 			// Create the table header
-			buffer.WriteString("| Task | Provider | Model | Result |\n")
-			buffer.WriteString("|------|----------|-------|--------|\n")
+			buffer.WriteString("| Task | Provider | Model | Result | Score |\n")
+			buffer.WriteString("|------|----------|-------|--------|-------|\n")
 
 			// Track success and failure counts for this strategy
 			successCount := 0
@@ -679,18 +1112,21 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 					failCount++
 				}
 
-				buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s %s |\n",
-					result.Task,
+				buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s %s%s | %.2f |\n",
+					resultTaskName(result),
 					result.LLMConfig.ProviderID,
 					result.LLMConfig.ModelID,
-					resultEmoji, result.Result))
+					resultEmoji, result.Result, formatSetScore(result), resultScore(result)))
 			}
 
 			// Add summary for this toolUseShimStr
 			buffer.WriteString(fmt.Sprintf("\n**%s Summary**\n\n", toolUseShimStr))
 			buffer.WriteString(fmt.Sprintf("- Total: %d\n", totalCount))
 			buffer.WriteString(fmt.Sprintf("- Success: %d (%d%%)\n", successCount, calculatePercentage(successCount, totalCount)))
-			buffer.WriteString(fmt.Sprintf("- Fail: %d (%d%%)\n\n", failCount, calculatePercentage(failCount, totalCount)))
+			buffer.WriteString(fmt.Sprintf("- Fail: %d (%d%%)\n", failCount, calculatePercentage(failCount, totalCount)))
+			buffer.WriteString(fmt.Sprintf("- Mean Score: %.2f\n", meanScore(toolUseShimStrResults)))
+			printClarificationRounds(&buffer, toolUseShimStrResults)
+			buffer.WriteString("\n")
 
 			// After the summary, print failure details
 			if config.ShowFailures {
@@ -720,6 +1156,244 @@ func printMarkdownResults(config AnalyzeConfig, results []model.TaskResult, resu
 	return nil
 }
 
+// loadTaskDefinitions reads every task.yaml below tasksDir (including disabled tasks and
+// nested task directories such as tasks/gatekeeper), keyed by task ID: the directory relative
+// to tasksDir, as in the results.
+func loadTaskDefinitions(tasksDir string) (map[string]Task, error) {
+	tasks := make(map[string]Task)
+	err := filepath.Walk(tasksDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != "task.yaml" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading file %s: %w", path, err)
+		}
+		var task Task
+		if err := yaml.Unmarshal(data, &task); err != nil {
+			return fmt.Errorf("parsing yaml from %s: %w", path, err)
+		}
+		taskID, err := filepath.Rel(tasksDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		tasks[filepath.ToSlash(taskID)] = task
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// printBreakdown writes a table of per-model results for each group (e.g. each difficulty or tag).
+func printBreakdown(buffer *strings.Builder, groupTitle string, groups map[string][]model.TaskResult, models []string) {
+	groupNames := make([]string, 0, len(groups))
+	for name := range groups {
+		groupNames = append(groupNames, name)
+	}
+	sort.Strings(groupNames)
+
+	buffer.WriteString(fmt.Sprintf("## Results by %s\n\n", groupTitle))
+	buffer.WriteString(fmt.Sprintf("| %s | Model | Runs | Success | Fail | Error | Mean Score |\n", groupTitle))
+	buffer.WriteString("|------|-------|------|---------|------|-------|------------|\n")
+	for _, name := range groupNames {
+		for _, modelID := range models {
+			var modelResults []model.TaskResult
+			successCount := 0
+			failCount := 0
+			errorCount := 0
+			for _, result := range groups[name] {
+				if result.LLMConfig.ModelID != modelID {
+					continue
+				}
+				modelResults = append(modelResults, result)
+				if strings.Contains(strings.ToLower(result.Result), "success") {
+					successCount++
+				} else if strings.Contains(strings.ToLower(result.Result), "fail") {
+					failCount++
+				} else {
+					errorCount++
+				}
+			}
+			if len(modelResults) == 0 {
+				continue
+			}
+			buffer.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %d | %d | %.2f |\n", name, modelID, len(modelResults), successCount, failCount, errorCount, meanScore(modelResults)))
+		}
+	}
+	buffer.WriteString("\n")
+}
+
+// printVariantConsistency writes, for each task run with several prompt variants, how many variants
+// each LLM config passed and how consistent its outcome was across them. Errors are not the model's
+// doing and are left out.
+func printVariantConsistency(buffer *strings.Builder, results []model.TaskResult) {
+	type key struct{ task, llmConfig string }
+	passed := make(map[key][]string)
+	failed := make(map[key][]string)
+	var tasks, ids []string
+	for _, result := range results {
+		if result.Variant == "" {
+			continue
+		}
+		k := key{result.Task, result.LLMConfig.ID}
+		if strings.Contains(strings.ToLower(result.Result), "success") {
+			passed[k] = append(passed[k], result.Variant)
+		} else if strings.Contains(strings.ToLower(result.Result), "fail") {
+			failed[k] = append(failed[k], result.Variant)
+		} else {
+			continue
+		}
+		if !slices.Contains(tasks, result.Task) {
+			tasks = append(tasks, result.Task)
+		}
+		if !slices.Contains(ids, k.llmConfig) {
+			ids = append(ids, k.llmConfig)
+		}
+	}
+	if len(tasks) == 0 {
+		return
+	}
+	sort.Strings(tasks)
+	sort.Strings(ids)
+
+	type summary struct {
+		tasks, consistent int
+		consistency       float64
+	}
+	summaries := make(map[string]*summary)
+
+	buffer.WriteString("## Prompt Variant Consistency\n\n")
+	buffer.WriteString("| Task | LLM Config | Variants | Passed | Consistency | Failed Variants |\n")
+	buffer.WriteString("|------|------------|----------|--------|-------------|-----------------|\n")
+	for _, task := range tasks {
+		for _, id := range ids {
+			k := key{task, id}
+			passCount, failCount := len(passed[k]), len(failed[k])
+			n := passCount + failCount
+			if n < 2 {
+				continue
+			}
+			// Consistency is the share of variants that agree with the majority outcome.
+			consistency := float64(max(passCount, failCount)) / float64(n)
+			failedVariants := failed[k]
+			sort.Strings(failedVariants)
+			buffer.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %.2f | %s |\n", task, id, n, passCount, consistency, strings.Join(failedVariants, ", ")))
+
+			if summaries[id] == nil {
+				summaries[id] = &summary{}
+			}
+			s := summaries[id]
+			s.tasks++
+			s.consistency += consistency
+			if passCount == 0 || failCount == 0 {
+				s.consistent++
+			}
+		}
+	}
+	buffer.WriteString("\n")
+	for _, id := range ids {
+		if s := summaries[id]; s != nil {
+			buffer.WriteString(fmt.Sprintf("- %s: same outcome for every variant on %d of %d tasks, mean consistency %.2f\n", id, s.consistent, s.tasks, s.consistency/float64(s.tasks)))
+		}
+	}
+	buffer.WriteString("\n")
+}
+
+// printClarificationRounds writes how many clarifying questions the simulated user answered, if any.
+func printClarificationRounds(buffer *strings.Builder, results []model.TaskResult) {
+	total, runs := 0, 0
+	for _, result := range results {
+		if result.ClarificationRounds > 0 {
+			total += result.ClarificationRounds
+			runs++
+		}
+	}
+	if runs == 0 {
+		return
+	}
+	buffer.WriteString(fmt.Sprintf("- Clarification Rounds: %d in %d runs (mean %.2f per run)\n", total, runs, float64(total)/float64(len(results))))
+}
+
+// resultTaskName returns the task name of a result, qualified with its prompt variant if it has one.
+func resultTaskName(result model.TaskResult) string {
+	if result.Variant == "" {
+		return result.Task
+	}
+	return fmt.Sprintf("%s (%s)", result.Task, result.Variant)
+}
+
+// resultScore returns the partial-credit score of a result.
+// Results written before scores were introduced have no checks; they score 1 on success and 0 otherwise.
+func resultScore(result model.TaskResult) float64 {
+	if len(result.Checks) == 0 {
+		if strings.Contains(strings.ToLower(result.Result), "success") {
+			return 1
+		}
+		return 0
+	}
+	return result.Score
+}
+
+// meanScore returns the mean partial-credit score of the results that succeeded or failed;
+// errors and the like are infrastructure failures, which say nothing about the model.
+func meanScore(results []model.TaskResult) float64 {
+	var total float64
+	var n int
+	for _, result := range results {
+		outcome := strings.ToLower(result.Result)
+		if !strings.Contains(outcome, "success") && !strings.Contains(outcome, "fail") {
+			continue
+		}
+		total += resultScore(result)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// formatSetScore summarizes the set expectations of a result, so partial answers are visible.
+func formatSetScore(result model.TaskResult) string {
+	if len(result.SetScores) == 0 {
+		return ""
+	}
+	var tp, fp, fn int
+	for _, score := range result.SetScores {
+		tp += len(score.TruePositives)
+		fp += len(score.FalsePositives)
+		fn += len(score.FalseNegatives)
+	}
+	meanF1, _ := meanSetF1([]model.TaskResult{result})
+	return fmt.Sprintf(" (F1 %.2f, TP %d, FP %d, FN %d)", meanF1, tp, fp, fn)
+}
+
+// meanSetF1 returns the mean F1 of set expectations across results, and the number of results that had any.
+func meanSetF1(results []model.TaskResult) (float64, int) {
+	var total float64
+	n := 0
+	for _, result := range results {
+		if len(result.SetScores) == 0 {
+			continue
+		}
+		var sum float64
+		for _, score := range result.SetScores {
+			sum += score.F1
+		}
+		total += sum / float64(len(result.SetScores))
+		n++
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	return total / float64(n), n
+}
+
 func calculatePercentage(part, total int) int {
 	if total == 0 {
 		return 0
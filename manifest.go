@@ -0,0 +1,411 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/agent"
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/pool"
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+	"github.com/gke-labs/k8s-ai-bench/pkg/preflight"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// manifestFile records how the results in an output directory were produced. When a run is resumed,
+// the manifest of the previous run is kept as run-<run id>.yaml.
+const manifestFile = "run.yaml"
+
+// RunManifest is the contents of the manifest file of a run.
+type RunManifest struct {
+	RunID     string     `json:"runID"`
+	StartTime time.Time  `json:"startTime"`
+	EndTime   *time.Time `json:"endTime,omitempty"`
+
+	// Interrupted is set if the run was stopped by a signal before all tasks ran.
+	Interrupted bool `json:"interrupted,omitempty"`
+
+	// Config is the configuration of the run, including the resolved LLM configs.
+	Config EvalConfig `json:"config"`
+
+	// Agents describes each agent benchmarked by the run.
+	Agents []AgentInfo `json:"agents"`
+	// Agent is the only agent of manifests written before a run could benchmark several agents.
+	Agent *AgentInfo `json:"agent,omitempty"`
+
+	Cluster ClusterInfo `json:"cluster"`
+	Harness HarnessInfo `json:"harness"`
+
+	// Tasks maps the ID of each task used to a hash of the contents of its directory.
+	Tasks map[string]string `json:"tasks"`
+
+	// TurnByTurnTasks are the tasks run interactively whatever the LLM configs' quiet mode, because
+	// they have several script steps, step checks or a simulated user.
+	TurnByTurnTasks []string `json:"turnByTurnTasks,omitempty"`
+}
+
+// AgentInfo identifies the agent: its binary, or its URL for an HTTP agent.
+type AgentInfo struct {
+	Type    string `json:"type,omitempty"`
+	Name    string `json:"name,omitempty"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+}
+
+// ClusterInfo describes the cluster the tasks ran against.
+type ClusterInfo struct {
+	Provider          string `json:"provider"`
+	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
+
+	// Pool reports the use of the cluster pool, if one was used (see --cluster-pool-size).
+	Pool *pool.Stats `json:"pool,omitempty"`
+}
+
+// HarnessInfo identifies the build of k8s-ai-bench that ran the tasks.
+type HarnessInfo struct {
+	// Revision is the git commit the binary was built from, if known.
+	Revision string `json:"revision,omitempty"`
+
+	// Modified is set if the binary was built from a tree with uncommitted changes.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// newRunID returns a run ID from the start time, with a random suffix to tell apart runs started together.
+func newRunID(start time.Time) string {
+	suffix := make([]byte, 4)
+	for i := range suffix {
+		suffix[i] = suffixAlphabet[rand.Intn(len(suffixAlphabet))]
+	}
+	return start.UTC().Format("20060102-150405") + "-" + string(suffix)
+}
+
+// newRunManifest describes a run of the tasks with config. Metadata that cannot be determined
+// (e.g. an agent without a version command) is logged and left out rather than failing the run.
+func newRunManifest(ctx context.Context, config EvalConfig, tasks map[string]Task) (*RunManifest, error) {
+	log := klog.FromContext(ctx)
+
+	start := time.Now()
+	manifest := &RunManifest{
+		RunID:     newRunID(start),
+		StartTime: start,
+		Config:    config,
+		Cluster:   ClusterInfo{Provider: config.ClusterProvider},
+		Harness:   harnessInfo(),
+		Tasks:     make(map[string]string),
+	}
+
+	var agentConfigs []model.AgentConfig
+	for _, llmConfig := range config.LLMConfigs {
+		if !slices.ContainsFunc(agentConfigs, func(a model.AgentConfig) bool { return reflect.DeepEqual(a, llmConfig.Agent) }) {
+			agentConfigs = append(agentConfigs, llmConfig.Agent)
+		}
+	}
+	for _, agentConfig := range agentConfigs {
+		manifest.Agents = append(manifest.Agents, agentInfo(ctx, agentConfig, config.AgentBin))
+	}
+
+	if config.KubeConfig != "" {
+		version, err := preflight.ServerVersion(ctx, config.KubeConfig)
+		if err != nil {
+			log.Info("could not determine the Kubernetes version for the run manifest", "err", err)
+		}
+		manifest.Cluster.KubernetesVersion = version
+	}
+
+	for taskID := range tasks {
+		hash, err := hashDir(filepath.Join(config.TasksDir, taskID))
+		if err != nil {
+			return nil, fmt.Errorf("hashing task %s: %w", taskID, err)
+		}
+		manifest.Tasks[taskID] = hash
+		if task := tasks[taskID]; task.runsTurnByTurn() {
+			manifest.TurnByTurnTasks = append(manifest.TurnByTurnTasks, taskID)
+		}
+	}
+	sort.Strings(manifest.TurnByTurnTasks)
+	return manifest, nil
+}
+
+// writeRunManifest writes the manifest to outputDir. An existing manifest of an earlier run
+// (e.g. one being resumed) is renamed so that it is kept alongside.
+func writeRunManifest(outputDir string, manifest *RunManifest) error {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("creating directory %q: %w", outputDir, err)
+	}
+	p := filepath.Join(outputDir, manifestFile)
+	if data, err := os.ReadFile(p); err == nil {
+		var previous RunManifest
+		if err := yaml.Unmarshal(data, &previous); err == nil && previous.RunID != "" && previous.RunID != manifest.RunID {
+			if err := os.Rename(p, filepath.Join(outputDir, "run-"+pathSegment(previous.RunID)+".yaml")); err != nil {
+				return fmt.Errorf("keeping manifest of previous run: %w", err)
+			}
+		}
+	}
+	if err := writeToYAMLFile(p, manifest); err != nil {
+		return fmt.Errorf("writing run manifest: %w", err)
+	}
+	return nil
+}
+
+func agentInfo(ctx context.Context, agentConfig model.AgentConfig, agentBin string) AgentInfo {
+	log := klog.FromContext(ctx)
+
+	if agentConfig.Bin != "" {
+		agentBin = agentConfig.Bin
+	}
+	info := AgentInfo{Type: agentConfig.Type, Name: agentConfig.Name, Path: agentBin}
+	switch agentConfig.Type {
+	case agent.TypeHTTP:
+		// There is no binary to hash, nor a version command to run.
+		info.Path = agentConfig.URL
+		return info
+	case agent.TypeCommand:
+		// The command's arguments may be templates, but its binary is usually a plain path.
+		agentBin = agentConfig.Command[0]
+		info.Path = agentBin
+	}
+	path, err := exec.LookPath(agentBin)
+	if err != nil {
+		log.Info("agent binary not found for the run manifest", "path", agentBin, "err", err)
+		return info
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	info.Path = path
+
+	if hash, err := hashFile(path); err != nil {
+		log.Info("could not hash agent binary for the run manifest", "path", path, "err", err)
+	} else {
+		info.SHA256 = hash
+	}
+
+	versionCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	out, err := exec.CommandContext(versionCtx, path, "version").Output()
+	if err != nil {
+		log.Info("could not determine agent version for the run manifest", "path", path, "err", err)
+		return info
+	}
+	// Only the first line: some agents print build details after the version.
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	if scanner.Scan() {
+		info.Version = strings.TrimSpace(scanner.Text())
+	}
+	return info
+}
+
+func harnessInfo() HarnessInfo {
+	var info HarnessInfo
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashDir returns a hash of the relative paths and contents of the files in dir, which changes
+// whenever any file of a task (task.yaml, scripts, artifacts) is added, removed or edited.
+func hashDir(dir string) (string, error) {
+	h := sha256.New()
+	// Walk visits files in lexical order, so the hash does not depend on the file system.
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", filepath.ToSlash(rel), len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// collectManifests reads the manifests of the runs in inputDir, including those of resumed runs.
+func collectManifests(inputDir string) ([]RunManifest, error) {
+	var manifests []RunManifest
+	err := filepath.Walk(inputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == attemptsDir {
+			return filepath.SkipDir
+		}
+		name := info.Name()
+		if info.IsDir() || !(name == manifestFile || strings.HasPrefix(name, "run-") && strings.HasSuffix(name, ".yaml")) {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading file %s: %w", path, err)
+		}
+		var manifest RunManifest
+		if err := yaml.Unmarshal(data, &manifest); err != nil || manifest.RunID == "" {
+			// Not a run manifest.
+			return nil
+		}
+		manifests = append(manifests, manifest)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(manifests, func(i, j int) bool {
+		return manifests[i].StartTime.Before(manifests[j].StartTime)
+	})
+	return manifests, nil
+}
+
+// taskHashMismatches returns a warning for each task whose contents differ between the runs,
+// as the results of such runs are not comparable.
+func taskHashMismatches(manifests []RunManifest) []string {
+	runsByHash := make(map[string]map[string][]string) // task -> hash -> run IDs
+	for _, manifest := range manifests {
+		for taskID, hash := range manifest.Tasks {
+			if runsByHash[taskID] == nil {
+				runsByHash[taskID] = make(map[string][]string)
+			}
+			runsByHash[taskID][hash] = append(runsByHash[taskID][hash], manifest.RunID)
+		}
+	}
+
+	var warnings []string
+	for taskID, runs := range runsByHash {
+		if len(runs) < 2 {
+			continue
+		}
+		var versions []string
+		for _, runIDs := range runs {
+			versions = append(versions, strings.Join(runIDs, ", "))
+		}
+		sort.Strings(versions)
+		warnings = append(warnings, fmt.Sprintf("task %s differs between runs: [%s]", taskID, strings.Join(versions, "] vs [")))
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+// printRuns writes the metadata of the runs whose results are analyzed, and warns about
+// tasks that changed between them.
+func printRuns(buffer *strings.Builder, manifests []RunManifest) {
+	if len(manifests) == 0 {
+		return
+	}
+	buffer.WriteString("## Runs\n\n")
+	buffer.WriteString("| Run ID | Started | Duration | Harness | Agent Version | Cluster |\n")
+	buffer.WriteString("|--------|---------|----------|---------|---------------|---------|\n")
+	for _, m := range manifests {
+		duration := "incomplete"
+		if m.EndTime != nil {
+			duration = m.EndTime.Sub(m.StartTime).Round(time.Second).String()
+		}
+		if m.Interrupted {
+			duration += " (interrupted)"
+		}
+		harness := "unknown"
+		if m.Harness.Revision != "" {
+			harness = m.Harness.Revision[:min(len(m.Harness.Revision), 12)]
+			if m.Harness.Modified {
+				harness += " (modified)"
+			}
+		}
+		agents := m.Agents
+		if m.Agent != nil {
+			agents = append(agents, *m.Agent)
+		}
+		var agentVersions []string
+		for _, info := range agents {
+			version := info.Version
+			if version == "" {
+				version = "unknown"
+			}
+			if info.Name != "" {
+				version = info.Name + ": " + version
+			}
+			agentVersions = append(agentVersions, version)
+		}
+		agentVersion := strings.Join(agentVersions, ", ")
+		if agentVersion == "" {
+			agentVersion = "unknown"
+		}
+		clusterInfo := m.Cluster.Provider
+		if m.Cluster.KubernetesVersion != "" {
+			clusterInfo += " " + m.Cluster.KubernetesVersion
+		}
+		buffer.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s |\n",
+			m.RunID, m.StartTime.UTC().Format(time.RFC3339), duration, harness, agentVersion, clusterInfo))
+	}
+	buffer.WriteString("\n")
+
+	if warnings := taskHashMismatches(manifests); len(warnings) > 0 {
+		buffer.WriteString("**Warning:** some tasks changed between the runs analyzed together, so their results are not comparable:\n\n")
+		for _, warning := range warnings {
+			buffer.WriteString(fmt.Sprintf("- %s\n", warning))
+		}
+		buffer.WriteString("\n")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
+	"k8s.io/klog/v2"
+	"sigs.k8s.io/yaml"
+)
+
+// taskNamespaceVariable is the variable (and environment variable) holding the namespace
+// created for a task in IsolationModeNamespace.
+const taskNamespaceVariable = "TASK_NAMESPACE"
+
+// agentServiceAccount is the ServiceAccount the agent acts as in IsolationModeNamespace.
+const agentServiceAccount = "k8s-ai-bench-agent"
+
+var invalidNamespaceChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// isolatedNamespaceName returns a unique, DNS-compatible namespace name for an execution of the task.
+func isolatedNamespaceName(taskID string) string {
+	prefix := strings.Trim(invalidNamespaceChars.ReplaceAllString(strings.ToLower(taskID), "-"), "-")
+	// Leave room for the random suffix within the 63 character limit.
+	if len(prefix) > 50 {
+		prefix = strings.TrimRight(prefix[:50], "-")
+	}
+	suffix := make([]byte, 6)
+	for i := range suffix {
+		suffix[i] = suffixAlphabet[rand.Intn(len(suffixAlphabet))]
+	}
+	return fmt.Sprintf("%s-%s", prefix, suffix)
+}
+
+// setupNamespaceIsolation creates the task namespace and a ServiceAccount with admin rights in it,
+// and writes a kubeconfig for the agent that authenticates as that ServiceAccount.
+// Scripts and verify assertions keep using the original kubeconfig.
+func (x *TaskExecution) setupNamespaceIsolation(ctx context.Context) error {
+	log := klog.FromContext(ctx)
+	namespace := x.namespace
+	log.Info("creating namespace", "name", namespace)
+
+	if _, err := verify.RunKubectl(ctx, x.kubeConfig, "create", "namespace", namespace); err != nil {
+		return fmt.Errorf("failed to create isolated namespace %q: %w", namespace, err)
+	}
+	x.cleanupFunctions = append(x.cleanupFunctions, func(ctx context.Context) error {
+		_, err := verify.RunKubectl(ctx, x.kubeConfig, "delete", "namespace", namespace, "--ignore-not-found", "--wait=false")
+		return err
+	})
+
+	if _, err := verify.RunKubectl(ctx, x.kubeConfig, "create", "serviceaccount", agentServiceAccount, "--namespace", namespace); err != nil {
+		return fmt.Errorf("failed to create service account in namespace %q: %w", namespace, err)
+	}
+	if _, err := verify.RunKubectl(ctx, x.kubeConfig, "create", "rolebinding", agentServiceAccount,
+		"--namespace", namespace,
+		"--clusterrole", "admin",
+		"--serviceaccount", namespace+":"+agentServiceAccount); err != nil {
+		return fmt.Errorf("failed to bind service account in namespace %q: %w", namespace, err)
+	}
+
+	// The token only needs to outlive the task; deleting the namespace revokes it anyway.
+	duration := time.Hour
+	if deadline, ok := ctx.Deadline(); ok {
+		duration = time.Until(deadline) + 10*time.Minute
+	}
+	token, err := verify.RunKubectl(ctx, x.kubeConfig, "create", "token", agentServiceAccount,
+		"--namespace", namespace,
+		"--duration", duration.Round(time.Second).String())
+	if err != nil {
+		return fmt.Errorf("failed to create token for service account in namespace %q: %w", namespace, err)
+	}
+
+	kubeconfig, err := x.namespacedKubeconfig(ctx, namespace, strings.TrimSpace(string(token)))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp("", fmt.Sprintf("k8s-ai-bench-%s-*.kubeconfig", namespace))
+	if err != nil {
+		return fmt.Errorf("creating kubeconfig for namespace %q: %w", namespace, err)
+	}
+	kubeconfigPath := f.Name()
+	x.cleanupFunctions = append(x.cleanupFunctions, func(ctx context.Context) error {
+		if err := os.Remove(kubeconfigPath); err != nil {
+			log.Error(err, "failed to remove kubeconfig file", "path", kubeconfigPath)
+		}
+		return nil
+	})
+	_, err = f.Write(kubeconfig)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing kubeconfig for namespace %q: %w", namespace, err)
+	}
+	x.agentKubeConfig = kubeconfigPath
+	return nil
+}
+
+// namespacedKubeconfig builds a kubeconfig for the current cluster that authenticates with token
+// and defaults to namespace.
+func (x *TaskExecution) namespacedKubeconfig(ctx context.Context, namespace, token string) ([]byte, error) {
+	out, err := verify.RunKubectl(ctx, x.kubeConfig, "config", "view", "--raw", "--minify", "--flatten", "-o", "json")
+	if err != nil {
+		return nil, fmt.Errorf("reading kubeconfig: %w", err)
+	}
+	var current struct {
+		Clusters []struct {
+			Cluster map[string]any `json:"cluster"`
+		} `json:"clusters"`
+	}
+	if err := json.Unmarshal(out, &current); err != nil {
+		return nil, fmt.Errorf("parsing kubeconfig: %w", err)
+	}
+	if len(current.Clusters) == 0 {
+		return nil, fmt.Errorf("kubeconfig %q has no current cluster", x.kubeConfig)
+	}
+
+	name := "k8s-ai-bench"
+	kubeconfig := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Config",
+		"clusters": []any{
+			map[string]any{"name": name, "cluster": current.Clusters[0].Cluster},
+		},
+		"users": []any{
+			map[string]any{"name": agentServiceAccount, "user": map[string]any{"token": token}},
+		},
+		"contexts": []any{
+			map[string]any{"name": name, "context": map[string]any{
+				"cluster":   name,
+				"user":      agentServiceAccount,
+				"namespace": namespace,
+			}},
+		},
+		"current-context": name,
+	}
+	return yaml.Marshal(kubeconfig)
+}
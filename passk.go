@@ -0,0 +1,147 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// repeatedRuns are the runs of one task (prompt variant) by one LLM config.
+type repeatedRuns struct {
+	task, llmConfig string
+	runs            int
+	successes       int
+}
+
+// passAtK estimates the probability that at least one of k runs succeeds, from n runs with c successes
+// (the unbiased estimator 1 - C(n-c, k) / C(n, k)).
+func passAtK(n, c, k int) float64 {
+	if n-c < k {
+		return 1
+	}
+	return 1 - binomialRatio(n-c, n, k)
+}
+
+// passHatK estimates the probability that all of k runs succeed, C(c, k) / C(n, k).
+func passHatK(n, c, k int) float64 {
+	if c < k {
+		return 0
+	}
+	return binomialRatio(c, n, k)
+}
+
+// binomialRatio returns C(a, k) / C(b, k) for a <= b, computed as a product to avoid overflow.
+func binomialRatio(a, b, k int) float64 {
+	ratio := 1.0
+	for i := 0; i < k; i++ {
+		ratio *= float64(a-i) / float64(b-i)
+	}
+	return ratio
+}
+
+// printRepeatedRuns writes pass@1, pass@k, pass^k and the variance of success per task and LLM config,
+// for tasks that were run more than once (see --iterations). If k is 0 it is the smallest number of
+// runs of any task. Skipped and cancelled runs are left out; errors count as unsuccessful runs.
+func printRepeatedRuns(buffer *strings.Builder, results []model.TaskResult, k int) {
+	type key struct{ task, llmConfig string }
+	groups := make(map[key]*repeatedRuns)
+	for _, result := range results {
+		if result.Result == model.ResultSkipped || result.Result == model.ResultCancelled {
+			continue
+		}
+		kk := key{resultTaskName(result), result.LLMConfig.ID}
+		g := groups[kk]
+		if g == nil {
+			g = &repeatedRuns{task: kk.task, llmConfig: kk.llmConfig}
+			groups[kk] = g
+		}
+		g.runs++
+		if strings.Contains(strings.ToLower(result.Result), "success") {
+			g.successes++
+		}
+	}
+
+	var repeated []*repeatedRuns
+	var ids []string
+	minRuns := 0
+	for _, g := range groups {
+		if g.runs < 2 {
+			continue
+		}
+		repeated = append(repeated, g)
+		if !slices.Contains(ids, g.llmConfig) {
+			ids = append(ids, g.llmConfig)
+		}
+		if minRuns == 0 || g.runs < minRuns {
+			minRuns = g.runs
+		}
+	}
+	if len(repeated) == 0 {
+		return
+	}
+	if k <= 0 {
+		k = minRuns
+	}
+	sort.Strings(ids)
+	sort.Slice(repeated, func(i, j int) bool {
+		if repeated[i].llmConfig != repeated[j].llmConfig {
+			return repeated[i].llmConfig < repeated[j].llmConfig
+		}
+		return repeated[i].task < repeated[j].task
+	})
+
+	buffer.WriteString("## Repeated Runs\n\n")
+	buffer.WriteString(fmt.Sprintf("| LLM Config | Tasks | pass@1 | pass@%d | pass^%d | Mean Variance |\n", k, k))
+	buffer.WriteString("|------------|-------|--------|--------|--------|---------------|\n")
+	for _, id := range ids {
+		var tasks int
+		var pass1, passK, passHat, variance float64
+		for _, g := range repeated {
+			if g.llmConfig != id || g.runs < k {
+				continue
+			}
+			p := float64(g.successes) / float64(g.runs)
+			tasks++
+			pass1 += p
+			passK += passAtK(g.runs, g.successes, k)
+			passHat += passHatK(g.runs, g.successes, k)
+			variance += p * (1 - p)
+		}
+		if tasks == 0 {
+			continue
+		}
+		n := float64(tasks)
+		buffer.WriteString(fmt.Sprintf("| %s | %d | %.2f | %.2f | %.2f | %.3f |\n", id, tasks, pass1/n, passK/n, passHat/n, variance/n))
+	}
+	buffer.WriteString("\n")
+
+	buffer.WriteString(fmt.Sprintf("| Task | LLM Config | Runs | Successes | pass@1 | pass@%d | pass^%d | Variance |\n", k, k))
+	buffer.WriteString("|------|------------|------|-----------|--------|--------|--------|----------|\n")
+	for _, g := range repeated {
+		p := float64(g.successes) / float64(g.runs)
+		passK, passHat := "-", "-"
+		if g.runs >= k {
+			passK = fmt.Sprintf("%.2f", passAtK(g.runs, g.successes, k))
+			passHat = fmt.Sprintf("%.2f", passHatK(g.runs, g.successes, k))
+		}
+		buffer.WriteString(fmt.Sprintf("| %s | %s | %d | %d | %.2f | %s | %s | %.3f |\n", g.task, g.llmConfig, g.runs, g.successes, p, passK, passHat, p*(1-p)))
+	}
+	buffer.WriteString("\n")
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+func TestPassAtK(t *testing.T) {
+	tests := []struct {
+		n, c, k         int
+		passAt, passHat float64
+	}{
+		// No successes: no k runs can succeed.
+		{n: 5, c: 0, k: 1, passAt: 0, passHat: 0},
+		{n: 5, c: 0, k: 5, passAt: 0, passHat: 0},
+		// Only successes: every k runs succeed.
+		{n: 5, c: 5, k: 1, passAt: 1, passHat: 1},
+		{n: 5, c: 5, k: 5, passAt: 1, passHat: 1},
+		// k = 1 is the success rate.
+		{n: 4, c: 1, k: 1, passAt: 0.25, passHat: 0.25},
+		// 1 - C(2,2)/C(4,2) and C(2,2)/C(4,2).
+		{n: 4, c: 2, k: 2, passAt: 5.0 / 6, passHat: 1.0 / 6},
+		// Fewer failures than k: some run always succeeds.
+		{n: 4, c: 3, k: 2, passAt: 1, passHat: 0.5},
+		// Fewer successes than k: not every run can succeed.
+		{n: 4, c: 1, k: 2, passAt: 0.5, passHat: 0},
+		{n: 3, c: 2, k: 3, passAt: 1, passHat: 0},
+	}
+	for _, tt := range tests {
+		if got := passAtK(tt.n, tt.c, tt.k); math.Abs(got-tt.passAt) > 1e-9 {
+			t.Errorf("passAtK(%d, %d, %d) = %v, want %v", tt.n, tt.c, tt.k, got, tt.passAt)
+		}
+		if got := passHatK(tt.n, tt.c, tt.k); math.Abs(got-tt.passHat) > 1e-9 {
+			t.Errorf("passHatK(%d, %d, %d) = %v, want %v", tt.n, tt.c, tt.k, got, tt.passHat)
+		}
+	}
+}
+
+func TestPrintRepeatedRunsPerLLMConfig(t *testing.T) {
+	// Two LLM configs of the same model: one always succeeds, the other never does.
+	shim := model.LLMConfig{ID: "shim_enabled-gemini-gemini-2.5-pro", ModelID: "gemini-2.5-pro"}
+	noShim := model.LLMConfig{ID: "shim_disabled-gemini-gemini-2.5-pro", ModelID: "gemini-2.5-pro"}
+	var results []model.TaskResult
+	for iteration := 1; iteration <= 2; iteration++ {
+		results = append(results,
+			model.TaskResult{Task: "fix-probes", LLMConfig: shim, Result: "success", Iteration: iteration},
+			model.TaskResult{Task: "fix-probes", LLMConfig: noShim, Result: "fail", Iteration: iteration},
+		)
+	}
+
+	var buffer strings.Builder
+	printRepeatedRuns(&buffer, results, 0)
+	report := buffer.String()
+	for _, row := range []string{
+		"| shim_enabled-gemini-gemini-2.5-pro | 1 | 1.00 | 1.00 | 1.00 | 0.000 |",
+		"| shim_disabled-gemini-gemini-2.5-pro | 1 | 0.00 | 0.00 | 0.00 | 0.000 |",
+		"| fix-probes | shim_enabled-gemini-gemini-2.5-pro | 2 | 2 | 1.00 | 1.00 | 1.00 | 0.000 |",
+		"| fix-probes | shim_disabled-gemini-gemini-2.5-pro | 2 | 0 | 0.00 | 0.00 | 0.00 | 0.000 |",
+	} {
+		if !strings.Contains(report, row) {
+			t.Errorf("report is missing row %q:\n%s", row, report)
+		}
+	}
+}
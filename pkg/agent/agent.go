@@ -0,0 +1,138 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent runs the agents under test: kubectl-ai, any command line agent driven through
+// a command template, or an agent served over HTTP.
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// Agent types, see model.AgentConfig.
+const (
+	TypeKubectlAI = "kubectl-ai"
+	TypeCommand   = "command"
+	TypeHTTP      = "http"
+)
+
+// Types lists the agent types New accepts.
+var Types = []string{TypeKubectlAI, TypeCommand, TypeHTTP}
+
+// stopGracePeriod is how long an agent gets to exit after being interrupted before it is killed.
+const stopGracePeriod = 10 * time.Second
+
+// Request describes the task execution an agent is started for.
+type Request struct {
+	TaskID  string
+	TaskDir string
+
+	// KubeConfig is the path of the kubeconfig the agent must use.
+	KubeConfig string
+
+	LLM model.LLMConfig
+
+	// TracePath is where the agent should write its trace, if it supports one.
+	TracePath string
+
+	// Output receives the agent's transcript; Stderr its diagnostics.
+	Output io.Writer
+	Stderr io.Writer
+}
+
+// Agent starts sessions of an agent.
+type Agent interface {
+	// Start starts the agent; the session ends when the agent exits or ctx is done.
+	Start(ctx context.Context, req Request) (Session, error)
+
+	// Interactive reports whether the agent converses turn by turn. Other agents read all messages
+	// before they respond, so the harness cannot wait for a turn to end before sending the next message.
+	Interactive(llm model.LLMConfig) bool
+}
+
+// Session is a running agent.
+type Session interface {
+	// Send sends a user message (a prompt or an answer to a question) to the agent.
+	Send(message string) error
+
+	// Close tells the agent that no more messages follow.
+	Close() error
+
+	// Done is closed when the agent has exited.
+	Done() <-chan struct{}
+
+	// Err returns why the agent failed, once Done is closed.
+	Err() error
+}
+
+// TurnSignaler is implemented by sessions that know when the agent has finished responding,
+// so the harness does not have to wait for the agent's output to go quiet.
+type TurnSignaler interface {
+	// TurnDone returns a channel that is closed once the agent has responded to the last message sent.
+	TurnDone() <-chan struct{}
+}
+
+// New returns the agent for the config. agentBin is the kubectl-ai binary, unless the config names one.
+func New(config model.AgentConfig, agentBin string) (Agent, error) {
+	switch config.Type {
+	case "", TypeKubectlAI:
+		if config.Bin != "" {
+			agentBin = config.Bin
+		}
+		if agentBin == "" {
+			return nil, fmt.Errorf("the kubectl-ai agent needs --agent-bin")
+		}
+		return &kubectlAI{bin: agentBin}, nil
+	case TypeCommand:
+		if len(config.Command) == 0 {
+			return nil, fmt.Errorf("command agent has no command")
+		}
+		return newCommandAgent(config)
+	case TypeHTTP:
+		if config.URL == "" {
+			return nil, fmt.Errorf("http agent has no url")
+		}
+		return &httpAgent{url: config.URL, client: defaultHTTPClient}, nil
+	}
+	return nil, fmt.Errorf("unknown agent type %q, valid types are %v", config.Type, Types)
+}
+
+// Validate checks that the agent of the LLM config can run it, before any task does.
+func Validate(llm model.LLMConfig, agentBin string) error {
+	if _, err := New(llm.Agent, agentBin); err != nil {
+		return err
+	}
+	if llm.BaseURL != "" && (llm.Agent.Type == "" || llm.Agent.Type == TypeKubectlAI) {
+		if _, ok := baseURLEnv[llm.ProviderID]; !ok {
+			return fmt.Errorf("kubectl-ai does not take a base URL for provider %q; set the provider's endpoint through env instead", llm.ProviderID)
+		}
+	}
+	return nil
+}
+
+// ID returns a short identifier of the agent for LLM config IDs; empty for the default kubectl-ai agent.
+func ID(config model.AgentConfig) string {
+	if config.Name != "" {
+		return config.Name
+	}
+	if config.Type == TypeKubectlAI {
+		return ""
+	}
+	return config.Type
+}
@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// defaultHTTPClient has no timeout: a turn can take as long as the task's timeout allows.
+var defaultHTTPClient = &http.Client{}
+
+// HTTPMessage is the body of the requests an HTTP agent receives, one per user message.
+// Messages of the same task execution share a session ID.
+type HTTPMessage struct {
+	SessionID string `json:"sessionID"`
+	TaskID    string `json:"taskID"`
+	Message   string `json:"message"`
+
+	// KubeConfig is the content of the kubeconfig the agent must use.
+	KubeConfig string `json:"kubeconfig"`
+
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	BaseURL  string `json:"baseURL,omitempty"`
+}
+
+// httpAgent is an agent served over HTTP: every user message is POSTed to its URL as an
+// HTTPMessage, and the response body, streamed as it is written, is the agent's transcript
+// for that turn.
+type httpAgent struct {
+	url    string
+	client *http.Client
+}
+
+func (a *httpAgent) Start(ctx context.Context, req Request) (Session, error) {
+	kubeConfig, err := os.ReadFile(req.KubeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("reading agent kubeconfig: %w", err)
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s := &httpSession{
+		agent:  a,
+		ctx:    ctx,
+		cancel: cancel,
+		req:    req,
+		message: HTTPMessage{
+			SessionID:  hex.EncodeToString(id),
+			TaskID:     req.TaskID,
+			KubeConfig: string(kubeConfig),
+			Provider:   req.LLM.ProviderID,
+			Model:      req.LLM.ModelID,
+			BaseURL:    req.LLM.BaseURL,
+		},
+		done: make(chan struct{}),
+	}
+	// The session ends when the harness closes it, or when the task times out.
+	context.AfterFunc(ctx, func() { s.finish(ctx.Err()) })
+	return s, nil
+}
+
+// Every response is a whole turn, so an HTTP agent always converses turn by turn.
+func (a *httpAgent) Interactive(llm model.LLMConfig) bool {
+	return true
+}
+
+type httpSession struct {
+	agent   *httpAgent
+	ctx     context.Context
+	cancel  context.CancelFunc
+	req     Request
+	message HTTPMessage
+
+	mu sync.Mutex
+	// turn is closed once the response to the last message has been read.
+	turn chan struct{}
+	done chan struct{}
+	once sync.Once
+	err  error
+}
+
+// Send POSTs the message and streams the response into the transcript in the background.
+func (s *httpSession) Send(message string) error {
+	m := s.message
+	m.Message = message
+	body, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, s.agent.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	turn := make(chan struct{})
+	s.mu.Lock()
+	previous := s.turn
+	s.turn = turn
+	s.mu.Unlock()
+	go func() {
+		defer close(turn)
+		// Turns do not overlap, so the transcript stays in order.
+		if previous != nil {
+			<-previous
+		}
+		if err := s.post(req); err != nil {
+			s.finish(err)
+		}
+	}()
+	return nil
+}
+
+func (s *httpSession) post(req *http.Request) error {
+	resp, err := s.agent.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending message to agent: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("agent responded with %s: %s", resp.Status, bytes.TrimSpace(b))
+	}
+	if _, err := io.Copy(s.req.Output, resp.Body); err != nil {
+		return fmt.Errorf("reading agent response: %w", err)
+	}
+	return nil
+}
+
+func (s *httpSession) TurnDone() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.turn == nil {
+		turn := make(chan struct{})
+		close(turn)
+		return turn
+	}
+	return s.turn
+}
+
+// Close ends the session once the response to the last message has been read.
+func (s *httpSession) Close() error {
+	turn := s.TurnDone()
+	go func() {
+		<-turn
+		s.finish(nil)
+	}()
+	return nil
+}
+
+func (s *httpSession) finish(err error) {
+	s.once.Do(func() {
+		// A session closed by the harness is not an error, even though it cancels the context.
+		s.err = err
+		close(s.done)
+		s.cancel()
+	})
+}
+
+func (s *httpSession) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *httpSession) Err() error {
+	return s.err
+}
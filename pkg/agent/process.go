@@ -0,0 +1,232 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sort"
+	"text/template"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// baseURLEnv is the environment variable kubectl-ai reads each provider's endpoint from.
+var baseURLEnv = map[string]string{
+	"openai":   "OPENAI_ENDPOINT",
+	"azopenai": "AZURE_OPENAI_ENDPOINT",
+	"ollama":   "OLLAMA_HOST",
+	"llamacpp": "LLAMACPP_HOST",
+}
+
+// kubectlAI runs the kubectl-ai CLI, sending messages on stdin.
+type kubectlAI struct {
+	bin string
+}
+
+func (a *kubectlAI) Start(ctx context.Context, req Request) (Session, error) {
+	args := []string{
+		"--kubeconfig", req.KubeConfig,
+		"--llm-provider", req.LLM.ProviderID,
+		fmt.Sprintf("--enable-tool-use-shim=%t", req.LLM.EnableToolUseShim),
+		fmt.Sprintf("--quiet=%t", req.LLM.Quiet),
+		"--model", req.LLM.ModelID,
+		"--trace-path", req.TracePath,
+		"--skip-permissions",
+		"--show-tool-output",
+	}
+	if req.LLM.McpClient {
+		args = append(args, "--mcp-client")
+	}
+	var env []string
+	if req.LLM.BaseURL != "" {
+		name, ok := baseURLEnv[req.LLM.ProviderID]
+		if !ok {
+			return nil, fmt.Errorf("kubectl-ai does not take a base URL for provider %q", req.LLM.ProviderID)
+		}
+		env = append(env, name+"="+req.LLM.BaseURL)
+	}
+	return startProcess(ctx, append([]string{a.bin}, args...), env, req)
+}
+
+// In quiet mode kubectl-ai reads all of stdin as a single query.
+func (a *kubectlAI) Interactive(llm model.LLMConfig) bool {
+	return !llm.Quiet
+}
+
+// TemplateData is what the command and environment templates of a command agent can refer to.
+type TemplateData struct {
+	TaskID     string
+	TaskDir    string
+	KubeConfig string
+	TracePath  string
+
+	Provider          string
+	Model             string
+	BaseURL           string
+	EnableToolUseShim bool
+	Quiet             bool
+	MCPClient         bool
+}
+
+// commandAgent runs any command, with arguments and environment templated from the task and
+// LLM config, sending messages on stdin like kubectl-ai.
+type commandAgent struct {
+	command []*template.Template
+	env     map[string]*template.Template
+}
+
+func newCommandAgent(config model.AgentConfig) (*commandAgent, error) {
+	a := &commandAgent{env: make(map[string]*template.Template)}
+	for i, arg := range config.Command {
+		t, err := template.New(fmt.Sprintf("command[%d]", i)).Option("missingkey=error").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing agent command: %w", err)
+		}
+		a.command = append(a.command, t)
+	}
+	for name, value := range config.Env {
+		t, err := template.New(name).Option("missingkey=error").Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing agent env: %w", err)
+		}
+		a.env[name] = t
+	}
+	// Catch references to unknown fields now rather than in every task.
+	for _, t := range a.templates() {
+		if _, err := execute(t, TemplateData{}); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+func (a *commandAgent) Start(ctx context.Context, req Request) (Session, error) {
+	data := TemplateData{
+		TaskID:            req.TaskID,
+		TaskDir:           req.TaskDir,
+		KubeConfig:        req.KubeConfig,
+		TracePath:         req.TracePath,
+		Provider:          req.LLM.ProviderID,
+		Model:             req.LLM.ModelID,
+		BaseURL:           req.LLM.BaseURL,
+		EnableToolUseShim: req.LLM.EnableToolUseShim,
+		Quiet:             req.LLM.Quiet,
+		MCPClient:         req.LLM.McpClient,
+	}
+	argv := make([]string, len(a.command))
+	for i, t := range a.command {
+		s, err := execute(t, data)
+		if err != nil {
+			return nil, err
+		}
+		argv[i] = s
+	}
+	var env []string
+	for name, t := range a.env {
+		s, err := execute(t, data)
+		if err != nil {
+			return nil, err
+		}
+		env = append(env, name+"="+s)
+	}
+	sort.Strings(env)
+	return startProcess(ctx, argv, env, req)
+}
+
+// Like kubectl-ai, a command agent in quiet mode is expected to read all of stdin before it responds.
+func (a *commandAgent) Interactive(llm model.LLMConfig) bool {
+	return !llm.Quiet
+}
+
+func (a *commandAgent) templates() []*template.Template {
+	templates := append([]*template.Template{}, a.command...)
+	for _, t := range a.env {
+		templates = append(templates, t)
+	}
+	return templates
+}
+
+func execute(t *template.Template, data TemplateData) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("rendering agent %s: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+// processSession is an agent process that reads messages on stdin, one per line.
+type processSession struct {
+	stdin io.WriteCloser
+	done  chan struct{}
+	err   error
+}
+
+// startProcess starts argv, followed by the LLM config's agent args, with KUBECONFIG set to the agent
+// kubeconfig and the LLM config's env, plus env.
+func startProcess(ctx context.Context, argv []string, env []string, req Request) (*processSession, error) {
+	argv = append(argv, req.LLM.AgentArgs...)
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	// Give the agent a chance to exit cleanly (and write its trace) when the task times out or the run is interrupted.
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = stopGracePeriod
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("creating stdin pipe: %w", err)
+	}
+	cmd.Stdout = req.Output
+	cmd.Stderr = req.Stderr
+	cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", req.KubeConfig))
+	var llmEnv []string
+	for name, value := range req.LLM.Env {
+		llmEnv = append(llmEnv, name+"="+os.ExpandEnv(value))
+	}
+	sort.Strings(llmEnv)
+	cmd.Env = append(cmd.Env, llmEnv...)
+	cmd.Env = append(cmd.Env, env...)
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	s := &processSession{stdin: stdin, done: make(chan struct{})}
+	go func() {
+		s.err = cmd.Wait()
+		close(s.done)
+	}()
+	return s, nil
+}
+
+func (s *processSession) Send(message string) error {
+	_, err := fmt.Fprintf(s.stdin, "%s\n", message)
+	return err
+}
+
+func (s *processSession) Close() error {
+	return s.stdin.Close()
+}
+
+func (s *processSession) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *processSession) Err() error {
+	return s.err
+}
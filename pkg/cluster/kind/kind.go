@@ -30,6 +30,16 @@ func New() cluster.Provider {
 	return &Provider{}
 }
 
+// Capabilities reports what the default kind cluster offers: a single node, and the
+// local-path "standard" storage class, which does not support volume expansion.
+func (p *Provider) Capabilities() cluster.Capabilities {
+	volumeExpansion := false
+	return cluster.Capabilities{
+		Nodes:           1,
+		VolumeExpansion: &volumeExpansion,
+	}
+}
+
 func (p *Provider) Exists(name string) (bool, error) {
 	cmd := exec.Command("kind", "get", "clusters")
 	output, err := cmd.Output()
@@ -0,0 +1,403 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pool keeps clusters of a cluster.Provider warm, so tasks that need a cluster of
+// their own lease one instead of creating and deleting a cluster for every execution.
+package pool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster"
+	"k8s.io/klog/v2"
+)
+
+// ResetStrategy is how a cluster is made ready for the next task when it is returned.
+type ResetStrategy string
+
+const (
+	// ResetClean deletes the namespaces, CRDs and cluster-scoped objects created since the cluster was created.
+	ResetClean ResetStrategy = "clean"
+
+	// ResetRecreate deletes the cluster and creates it again.
+	ResetRecreate ResetStrategy = "recreate"
+)
+
+// ResetStrategies lists the valid reset strategies.
+var ResetStrategies = []ResetStrategy{ResetClean, ResetRecreate}
+
+// Config configures a pool.
+type Config struct {
+	// Size is the number of clusters kept in the pool, which is also the number of tasks that can use one at a time.
+	Size int
+
+	// Reset is how returned clusters are reset.
+	Reset ResetStrategy
+
+	// NamePrefix is the prefix of the names of the clusters in the pool.
+	NamePrefix string
+}
+
+// Stats reports how well the pool served the tasks.
+type Stats struct {
+	Size  int           `json:"size"`
+	Reset ResetStrategy `json:"reset"`
+
+	// Hits counts the leases served by a ready cluster; Misses those that had to wait for a cluster
+	// to be created, reset or returned.
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+
+	// WaitTime is the total time spent waiting for a cluster by the leases that missed.
+	WaitTime string `json:"waitTime,omitempty"`
+
+	// Created counts the clusters created, including those recreated.
+	Created int `json:"created"`
+
+	// Resets counts the clusters reset on return; ResetFailures the clean resets that failed.
+	Resets        int `json:"resets"`
+	ResetFailures int `json:"resetFailures,omitempty"`
+
+	// Recycled counts the clusters replaced by a new one after a failure.
+	Recycled int `json:"recycled,omitempty"`
+
+	MeanResetTime string `json:"meanResetTime,omitempty"`
+	MaxResetTime  string `json:"maxResetTime,omitempty"`
+}
+
+// Lease is a cluster leased from the pool, to be given back with Return.
+type Lease struct {
+	cluster *pooledCluster
+}
+
+// Name is the name of the leased cluster.
+func (l *Lease) Name() string {
+	return l.cluster.name
+}
+
+// Kubeconfig is the kubeconfig of the leased cluster.
+func (l *Lease) Kubeconfig() []byte {
+	return l.cluster.kubeconfig
+}
+
+type pooledCluster struct {
+	name           string
+	kubeconfig     []byte
+	kubeconfigPath string
+
+	// baseline is the state of the cluster after creation, which ResetClean restores.
+	baseline snapshot
+}
+
+// Pool is a pool of clusters. It is safe for concurrent use.
+type Pool struct {
+	provider cluster.Provider
+	config   Config
+
+	mu   sync.Mutex
+	cond *sync.Cond
+	// idle holds the clusters ready to be leased.
+	idle []*pooledCluster
+	// total counts the clusters of the pool, including those being created, leased or reset.
+	total int
+	// nextID numbers the clusters of the pool.
+	nextID int
+	closed bool
+	// background tracks the creations and resets in progress.
+	background sync.WaitGroup
+
+	stats        Stats
+	waitTime     time.Duration
+	resetTime    time.Duration
+	maxResetTime time.Duration
+}
+
+// New returns a pool of clusters of the provider. Call Warm to create the clusters ahead of time,
+// and Close to delete them.
+func New(provider cluster.Provider, config Config) (*Pool, error) {
+	if config.Size < 1 {
+		return nil, fmt.Errorf("pool size must be at least 1")
+	}
+	switch config.Reset {
+	case ResetClean, ResetRecreate:
+	default:
+		return nil, fmt.Errorf("unknown reset strategy %q", config.Reset)
+	}
+	p := &Pool{
+		provider: provider,
+		config:   config,
+		stats:    Stats{Size: config.Size, Reset: config.Reset},
+	}
+	p.cond = sync.NewCond(&p.mu)
+	return p, nil
+}
+
+// Warm starts creating the clusters of the pool in the background.
+func (p *Pool) Warm(ctx context.Context) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for p.total < p.config.Size {
+		p.total++
+		p.background.Add(1)
+		go func() {
+			defer p.background.Done()
+			c, err := p.create(ctx)
+			if err != nil {
+				klog.FromContext(ctx).Error(err, "warming cluster pool")
+			}
+			p.add(c)
+		}()
+	}
+}
+
+// Lease returns a cluster for the exclusive use of the caller, waiting for one to be ready if needed.
+func (p *Pool) Lease(ctx context.Context) (*Lease, error) {
+	stop := context.AfterFunc(ctx, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.cond.Broadcast()
+	})
+	defer stop()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	start := time.Now()
+	missed := false
+	for {
+		if p.closed {
+			return nil, errors.New("cluster pool is closed")
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if n := len(p.idle); n > 0 {
+			c := p.idle[n-1]
+			p.idle = p.idle[:n-1]
+			if missed {
+				p.stats.Misses++
+				p.waitTime += time.Since(start)
+			} else {
+				p.stats.Hits++
+			}
+			return &Lease{cluster: c}, nil
+		}
+		missed = true
+		// A creation that failed leaves room in the pool; try again on behalf of this lease.
+		if p.total < p.config.Size {
+			p.total++
+			p.mu.Unlock()
+			c, err := p.create(ctx)
+			p.mu.Lock()
+			if err != nil {
+				p.total--
+				p.cond.Broadcast()
+				return nil, err
+			}
+			p.stats.Misses++
+			p.waitTime += time.Since(start)
+			return &Lease{cluster: c}, nil
+		}
+		p.cond.Wait()
+	}
+}
+
+// Return gives a leased cluster back to the pool, which resets it in the background. If recycle is set
+// (e.g. the task failed in a way that may have left the cluster broken), the cluster is replaced instead.
+func (p *Pool) Return(ctx context.Context, lease *Lease, recycle bool) {
+	// The reset outlives the caller's cleanup; Close bounds how long it is waited for.
+	ctx = context.WithoutCancel(ctx)
+	p.background.Add(1)
+	go func() {
+		defer p.background.Done()
+		log := klog.FromContext(ctx)
+
+		c := lease.cluster
+		start := time.Now()
+		if p.config.Reset == ResetClean && !recycle {
+			err := c.baseline.restore(ctx, c.kubeconfigPath)
+			if err == nil {
+				p.recordReset(time.Since(start))
+				p.add(c)
+				return
+			}
+			log.Error(err, "resetting pooled cluster, recycling it", "name", c.name)
+			p.mu.Lock()
+			p.stats.ResetFailures++
+			p.mu.Unlock()
+			recycle = true
+		}
+
+		if err := p.delete(c); err != nil {
+			log.Error(err, "deleting pooled cluster", "name", c.name)
+		}
+		p.mu.Lock()
+		closed := p.closed
+		p.mu.Unlock()
+		if closed {
+			p.add(nil)
+			return
+		}
+		replacement, err := p.create(ctx)
+		if err != nil {
+			log.Error(err, "replacing pooled cluster")
+		} else {
+			p.recordReset(time.Since(start))
+			if recycle {
+				p.mu.Lock()
+				p.stats.Recycled++
+				p.mu.Unlock()
+			}
+		}
+		p.add(replacement)
+	}()
+}
+
+// Stats returns the statistics of the pool so far.
+func (p *Pool) Stats() Stats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	if p.waitTime > 0 {
+		stats.WaitTime = p.waitTime.Round(time.Millisecond).String()
+	}
+	if stats.Resets > 0 {
+		stats.MeanResetTime = (p.resetTime / time.Duration(stats.Resets)).Round(time.Millisecond).String()
+		stats.MaxResetTime = p.maxResetTime.Round(time.Millisecond).String()
+	}
+	return stats
+}
+
+// Close waits for the resets in progress, until ctx is done, and deletes the clusters of the pool.
+// Clusters still leased are deleted when they are returned. Closing a closed pool has no effect.
+func (p *Pool) Close(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.cond.Broadcast()
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.background.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	var errs []error
+	for _, c := range idle {
+		errs = append(errs, p.delete(c))
+	}
+	select {
+	case <-done:
+	default:
+		errs = append(errs, fmt.Errorf("cluster pool resets did not finish in time; clusters named %s-* may need to be deleted manually", p.config.NamePrefix))
+	}
+	return errors.Join(errs...)
+}
+
+// add makes a created or reset cluster available, or deletes it if the pool was closed. A nil cluster
+// (one that could not be created) frees its place in the pool.
+func (p *Pool) add(c *pooledCluster) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.cond.Broadcast()
+	if c == nil {
+		p.total--
+		return
+	}
+	if p.closed {
+		p.mu.Unlock()
+		p.delete(c)
+		p.mu.Lock()
+		return
+	}
+	p.idle = append(p.idle, c)
+}
+
+func (p *Pool) recordReset(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.Resets++
+	p.resetTime += d
+	p.maxResetTime = max(p.maxResetTime, d)
+}
+
+// create creates a cluster for the pool and records its baseline state.
+func (p *Pool) create(ctx context.Context) (*pooledCluster, error) {
+	p.mu.Lock()
+	p.nextID++
+	name := fmt.Sprintf("%s-%d", p.config.NamePrefix, p.nextID)
+	p.mu.Unlock()
+
+	// A cluster left behind by an earlier run that did not shut down cleanly cannot be trusted.
+	if exists, err := p.provider.Exists(name); err == nil && exists {
+		if err := p.provider.Delete(name); err != nil {
+			return nil, fmt.Errorf("deleting stale cluster %q: %w", name, err)
+		}
+	}
+	c := &pooledCluster{name: name}
+	if err := p.provider.Create(name); err != nil {
+		return nil, fmt.Errorf("creating cluster %q: %w", name, err)
+	}
+	p.mu.Lock()
+	p.stats.Created++
+	p.mu.Unlock()
+
+	var err error
+	if c.kubeconfig, err = p.provider.GetKubeconfig(name); err != nil {
+		p.delete(c)
+		return nil, fmt.Errorf("getting kubeconfig of cluster %q: %w", name, err)
+	}
+	f, err := os.CreateTemp("", fmt.Sprintf("%s-*.kubeconfig", name))
+	if err != nil {
+		p.delete(c)
+		return nil, err
+	}
+	c.kubeconfigPath = f.Name()
+	_, err = f.Write(c.kubeconfig)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		p.delete(c)
+		return nil, fmt.Errorf("writing kubeconfig of cluster %q: %w", name, err)
+	}
+
+	if p.config.Reset == ResetClean {
+		if c.baseline, err = takeSnapshot(ctx, c.kubeconfigPath); err != nil {
+			p.delete(c)
+			return nil, fmt.Errorf("recording baseline of cluster %q: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+func (p *Pool) delete(c *pooledCluster) error {
+	if c.kubeconfigPath != "" {
+		os.Remove(c.kubeconfigPath)
+	}
+	return p.provider.Delete(c.name)
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
+)
+
+// systemNamespaces are never deleted by a reset, nor are the objects in them.
+var systemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease", "local-path-storage"}
+
+// resetNamespace is the namespace that cannot be deleted, so the objects created in it are deleted instead.
+const resetNamespace = "default"
+
+// ignoredResources are not deleted by a reset: they come and go with the cluster's own activity.
+var ignoredResources = []string{"events", "events.events.k8s.io", "nodes", "componentstatuses"}
+
+// snapshot is the set of objects in a cluster, as returned by kubectl get -o name.
+type snapshot struct {
+	namespaces   []string
+	crds         []string
+	clusterScope []string
+	// objects holds the objects in resetNamespace.
+	objects []string
+}
+
+// takeSnapshot lists the namespaces, CRDs, cluster-scoped objects and objects of the default namespace in the cluster.
+func takeSnapshot(ctx context.Context, kubeConfig string) (snapshot, error) {
+	var s snapshot
+	var err error
+	if s.namespaces, err = getNames(ctx, kubeConfig, "", "namespaces"); err != nil {
+		return s, err
+	}
+	if s.crds, err = getNames(ctx, kubeConfig, "", "customresourcedefinitions"); err != nil {
+		return s, err
+	}
+	clusterResources, err := deletableResources(ctx, kubeConfig, false)
+	if err != nil {
+		return s, err
+	}
+	if s.clusterScope, err = getNames(ctx, kubeConfig, "", clusterResources...); err != nil {
+		return s, err
+	}
+	namespacedResources, err := deletableResources(ctx, kubeConfig, true)
+	if err != nil {
+		return s, err
+	}
+	if s.objects, err = getNames(ctx, kubeConfig, resetNamespace, namespacedResources...); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// restore deletes what was created in the cluster since the snapshot was taken: webhooks and other
+// cluster-scoped objects first, so they cannot block the rest, then the objects of the default namespace,
+// the other namespaces and finally the CRDs.
+func (s snapshot) restore(ctx context.Context, kubeConfig string) error {
+	current, err := takeSnapshot(ctx, kubeConfig)
+	if err != nil {
+		return err
+	}
+	var namespaces []string
+	for _, ns := range added(s.namespaces, current.namespaces) {
+		if !slices.Contains(systemNamespaces, strings.TrimPrefix(ns, "namespace/")) {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	steps := []struct {
+		names []string
+		args  []string
+	}{
+		{added(s.clusterScope, current.clusterScope), nil},
+		{added(s.objects, current.objects), []string{"--namespace", resetNamespace}},
+		{namespaces, []string{"--timeout", "2m"}},
+		{added(s.crds, current.crds), []string{"--timeout", "2m"}},
+	}
+	for _, step := range steps {
+		if len(step.names) == 0 {
+			continue
+		}
+		args := append([]string{"delete", "--ignore-not-found"}, step.args...)
+		if _, err := verify.RunKubectl(ctx, kubeConfig, append(args, step.names...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// added returns the names in current that are not in baseline.
+func added(baseline, current []string) []string {
+	var names []string
+	for _, name := range current {
+		if !slices.Contains(baseline, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// deletableResources lists the cluster-scoped (or namespaced) resources that can be listed and deleted,
+// other than namespaces and CRDs, which restore handles separately.
+func deletableResources(ctx context.Context, kubeConfig string, namespaced bool) ([]string, error) {
+	out, err := verify.RunKubectl(ctx, kubeConfig, "api-resources", "--verbs=list,delete", "-o", "name", fmt.Sprintf("--namespaced=%t", namespaced))
+	if err != nil {
+		return nil, err
+	}
+	var resources []string
+	for _, resource := range strings.Fields(string(out)) {
+		if !slices.Contains(ignoredResources, resource) && resource != "namespaces" && resource != "customresourcedefinitions.apiextensions.k8s.io" {
+			resources = append(resources, resource)
+		}
+	}
+	return resources, nil
+}
+
+// getNames returns the names of the objects of the resources, e.g. "namespace/default", in namespace
+// if the resources are namespaced.
+func getNames(ctx context.Context, kubeConfig string, namespace string, resources ...string) ([]string, error) {
+	if len(resources) == 0 {
+		return nil, nil
+	}
+	args := []string{"get", "-o", "name", strings.Join(resources, ",")}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	out, err := verify.RunKubectl(ctx, kubeConfig, args...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
@@ -19,4 +19,18 @@ type Provider interface {
 	Create(name string) error
 	Delete(name string) error
 	GetKubeconfig(name string) ([]byte, error)
+
+	// Capabilities describes the clusters the provider creates.
+	Capabilities() Capabilities
+}
+
+// Capabilities describes what a freshly created cluster offers, so tasks whose requirements
+// cannot be met are skipped before a cluster is created for them.
+// Unset fields are unknown; such requirements are checked against the live cluster instead.
+type Capabilities struct {
+	// Nodes is the number of nodes in a created cluster (0 if unknown).
+	Nodes int
+
+	// VolumeExpansion reports whether the storage classes of a created cluster allow resizing volumes (nil if unknown).
+	VolumeExpansion *bool
 }
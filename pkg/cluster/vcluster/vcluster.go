@@ -44,6 +44,11 @@ func New(hostContext, hostKubeConfig, ingressExternalIP string) cluster.Provider
 	return p
 }
 
+// Capabilities are unknown for vclusters, since nodes and storage are synced from the host cluster.
+func (p *Provider) Capabilities() cluster.Capabilities {
+	return cluster.Capabilities{}
+}
+
 func (p *Provider) Exists(name string) (bool, error) {
 	args := []string{"list", "--output", "json"}
 	if p.HostContext != "" {
@@ -0,0 +1,160 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package judge grades free-form agent answers against a rubric, using a model as the judge.
+package judge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/llm"
+)
+
+// Stub is the name of the deterministic local judge.
+const Stub = "stub"
+
+// Request is an answer to be graded.
+type Request struct {
+	// Question is what the user asked the agent.
+	Question string
+
+	// Rubric describes what a good answer must contain.
+	Rubric string
+
+	// Reference is an optional example of a correct answer.
+	Reference string
+
+	// Answer is the agent's answer.
+	Answer string
+}
+
+// Verdict is the judge's grade for an answer.
+type Verdict struct {
+	// Score grades the answer from 0 (wrong) to 1 (fully meets the rubric).
+	Score float64 `json:"score"`
+
+	// Rationale explains the score.
+	Rationale string `json:"rationale"`
+}
+
+// Judge grades answers.
+type Judge interface {
+	Judge(ctx context.Context, req Request) (Verdict, error)
+}
+
+// New returns the judge for the provider: Stub, or an LLM provider (see llm.Providers) with the given model.
+func New(ctx context.Context, provider, model string) (Judge, error) {
+	if provider == Stub {
+		return StubJudge{}, nil
+	}
+	client, err := llm.New(ctx, provider, model)
+	if err != nil {
+		return nil, err
+	}
+	return &LLMJudge{Client: client}, nil
+}
+
+// Providers lists the judges New accepts.
+func Providers() []string {
+	return append([]string{Stub}, llm.Providers...)
+}
+
+// LLMJudge asks a model to grade the answer.
+type LLMJudge struct {
+	Client llm.Client
+}
+
+const systemPrompt = `You are an expert Kubernetes administrator grading the answer an AI assistant gave to a user.
+Grade the answer only against the rubric; the reference answer, if given, shows what a correct answer looks like but need not be matched word for word.
+Respond with only a JSON object of the form {"score": <number between 0 and 1>, "rationale": "<one or two sentences>"}, where 1 means the answer fully meets the rubric and 0 means it does not meet it at all.`
+
+func (j *LLMJudge) Judge(ctx context.Context, req Request) (Verdict, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "User question:\n%s\n\n", req.Question)
+	fmt.Fprintf(&b, "Rubric:\n%s\n\n", req.Rubric)
+	if req.Reference != "" {
+		fmt.Fprintf(&b, "Reference answer:\n%s\n\n", req.Reference)
+	}
+	fmt.Fprintf(&b, "Assistant answer:\n%s\n", req.Answer)
+
+	reply, err := j.Client.Generate(ctx, systemPrompt, b.String())
+	if err != nil {
+		return Verdict{}, err
+	}
+	return parseVerdict(reply)
+}
+
+var jsonObject = regexp.MustCompile(`(?s)\{.*\}`)
+
+// parseVerdict extracts the verdict from the judge's reply, tolerating surrounding text or code fences.
+func parseVerdict(reply string) (Verdict, error) {
+	var verdict Verdict
+	match := jsonObject.FindString(reply)
+	if match == "" {
+		return verdict, fmt.Errorf("judge reply contains no JSON verdict: %q", reply)
+	}
+	if err := json.Unmarshal([]byte(match), &verdict); err != nil {
+		return verdict, fmt.Errorf("parsing judge verdict %q: %w", match, err)
+	}
+	verdict.Score = min(max(verdict.Score, 0), 1)
+	return verdict, nil
+}
+
+// StubJudge is a deterministic judge for tests and offline runs. It scores the fraction of the
+// (non-trivial) words of the reference answer, or of the rubric if there is no reference, found in the answer.
+type StubJudge struct{}
+
+func (StubJudge) Judge(ctx context.Context, req Request) (Verdict, error) {
+	expected := req.Reference
+	if expected == "" {
+		expected = req.Rubric
+	}
+	want := words(expected)
+	if len(want) == 0 {
+		return Verdict{Score: 1, Rationale: "nothing to compare against"}, nil
+	}
+	got := words(req.Answer)
+	var missing []string
+	for _, w := range want {
+		if !slices.Contains(got, w) {
+			missing = append(missing, w)
+		}
+	}
+	verdict := Verdict{Score: float64(len(want)-len(missing)) / float64(len(want))}
+	verdict.Rationale = fmt.Sprintf("answer contains %d of %d expected words", len(want)-len(missing), len(want))
+	if len(missing) > 0 {
+		verdict.Rationale += fmt.Sprintf("; missing %s", strings.Join(missing, ", "))
+	}
+	return verdict, nil
+}
+
+// words returns the distinct lowercased words of s longer than three characters, in order of appearance.
+func words(s string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	}) {
+		w = strings.Trim(w, "-")
+		if len(w) > 3 && !slices.Contains(out, w) {
+			out = append(out, w)
+		}
+	}
+	return out
+}
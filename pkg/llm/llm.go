@@ -0,0 +1,81 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package llm provides the harness's own access to language models, used by helpers such as
+// the user simulator. The models under evaluation are driven by the agent, not by this package.
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"google.golang.org/genai"
+)
+
+// Client generates a single completion.
+type Client interface {
+	// Generate returns the model's reply to prompt, following the system instruction.
+	Generate(ctx context.Context, system, prompt string) (string, error)
+}
+
+// Providers lists the supported provider IDs.
+var Providers = []string{"gemini", "openai"}
+
+// New returns a client for the model of the given provider.
+func New(ctx context.Context, provider, model string) (Client, error) {
+	switch provider {
+	case "gemini":
+		return newGemini(ctx, model)
+	case "openai":
+		return newOpenAI(model), nil
+	default:
+		return nil, fmt.Errorf("unknown LLM provider %q, valid options are %s", provider, strings.Join(Providers, ", "))
+	}
+}
+
+type geminiClient struct {
+	client *genai.Client
+	model  string
+}
+
+// newGemini creates a Gemini client, using GEMINI_API_KEY if set and Vertex AI otherwise.
+func newGemini(ctx context.Context, model string) (*geminiClient, error) {
+	config := &genai.ClientConfig{Backend: genai.BackendVertexAI}
+	if apiKey := os.Getenv("GEMINI_API_KEY"); apiKey != "" {
+		config = &genai.ClientConfig{APIKey: apiKey, Backend: genai.BackendGeminiAPI}
+	}
+	client, err := genai.NewClient(ctx, config)
+	if err != nil {
+		return nil, fmt.Errorf("creating Gemini client: %w", err)
+	}
+	return &geminiClient{client: client, model: model}, nil
+}
+
+func (c *geminiClient) Generate(ctx context.Context, system, prompt string) (string, error) {
+	var config *genai.GenerateContentConfig
+	if system != "" {
+		config = &genai.GenerateContentConfig{SystemInstruction: genai.NewContentFromText(system, genai.RoleUser)}
+	}
+	result, err := c.client.Models.GenerateContent(ctx, c.model, genai.Text(prompt), config)
+	if err != nil {
+		return "", fmt.Errorf("calling Gemini model %s: %w", c.model, err)
+	}
+	text := strings.TrimSpace(result.Text())
+	if text == "" {
+		return "", fmt.Errorf("empty response from Gemini model %s", c.model)
+	}
+	return text, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const defaultOpenAIBaseURL = "https://api.openai.com/v1"
+
+// openAIClient talks to any server implementing the OpenAI chat completions API
+// (OpenAI, vLLM, Ollama, LiteLLM, ...), configured by OPENAI_BASE_URL and OPENAI_API_KEY.
+type openAIClient struct {
+	baseURL string
+	apiKey  string
+	model   string
+	http    *http.Client
+}
+
+func newOpenAI(model string) *openAIClient {
+	baseURL := os.Getenv("OPENAI_BASE_URL")
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &openAIClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  os.Getenv("OPENAI_API_KEY"),
+		model:   model,
+		http:    http.DefaultClient,
+	}
+}
+
+type openAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+func (c *openAIClient) Generate(ctx context.Context, system, prompt string) (string, error) {
+	var messages []openAIMessage
+	if system != "" {
+		messages = append(messages, openAIMessage{Role: "system", Content: system})
+	}
+	messages = append(messages, openAIMessage{Role: "user", Content: prompt})
+	body, err := json.Marshal(map[string]any{"model": c.model, "messages": messages})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling OpenAI-compatible model %s: %w", c.model, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("reading response from model %s: %w", c.model, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("calling OpenAI-compatible model %s: %s: %s", c.model, resp.Status, strings.TrimSpace(string(data)))
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message openAIMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &completion); err != nil {
+		return "", fmt.Errorf("parsing response from model %s: %w", c.model, err)
+	}
+	if len(completion.Choices) == 0 || strings.TrimSpace(completion.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("empty response from model %s", c.model)
+	}
+	return strings.TrimSpace(completion.Choices[0].Message.Content), nil
+}
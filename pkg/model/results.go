@@ -14,13 +14,21 @@
 
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type TaskResult struct {
 	Task      string    `json:"name"`
 	LLMConfig LLMConfig `json:"llmConfig"`
 	Result    string    `json:"result"`
 
+	// Difficulty, Category and Tags are the task's metadata when it was run, for the breakdowns of analyze.
+	Difficulty string   `json:"difficulty,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+
 	// Failure contains a list of test failures, if there were unmet expectations.
 	// These do not indicate an infrastructure failure, rather they are the details of a test failure.
 	Failures []Failure `json:"failures,omitempty"`
@@ -28,6 +36,197 @@ type TaskResult struct {
 	// Error contains the error message, if there was an unexpected error during the execution of the test.
 	// This normally indicates an infrastructure failure, rather than a test failure.
 	Error string `json:"error"`
+
+	// Score is the weighted fraction (0..1) of the checks that passed.
+	Score float64 `json:"score"`
+
+	// Checks is the per-check breakdown that produced Score.
+	Checks []Check `json:"checks,omitempty"`
+
+	// SetScores contains the precision/recall breakdown of set expectations.
+	SetScores []SetScore `json:"setScores,omitempty"`
+
+	// JudgeVerdicts contains the judge's score and rationale for each judge expectation.
+	JudgeVerdicts []JudgeVerdict `json:"judgeVerdicts,omitempty"`
+
+	// Assertions contains the outcome of each declarative verify assertion.
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+
+	// Steps contains the per-step results, for script steps that declare their own checks.
+	Steps []StepResult `json:"steps,omitempty"`
+
+	// FailedStep is the (1-based) index of the first script step whose checks failed, or 0.
+	FailedStep int `json:"failedStep,omitempty"`
+
+	// SkipReason explains why the task was skipped (see ResultSkipped).
+	SkipReason string `json:"skipReason,omitempty"`
+
+	// Seed is the run seed the task variables were sampled with; rerunning with --seed reproduces them.
+	Seed int64 `json:"seed,omitempty"`
+
+	// Variables are the sampled values of the task variables.
+	Variables map[string]string `json:"variables,omitempty"`
+
+	// ClarificationRounds is the number of clarifying questions the simulated user answered.
+	ClarificationRounds int `json:"clarificationRounds,omitempty"`
+
+	// Clarifications are the questions the agent asked and the simulated user's answers.
+	Clarifications []Clarification `json:"clarifications,omitempty"`
+
+	// Variant is the ID of the prompt variant that was run, for tasks with prompt variants.
+	Variant string `json:"variant,omitempty"`
+
+	// Iteration is the (1-based) repetition of the task, when each task is run several times.
+	Iteration int `json:"iteration,omitempty"`
+
+	// Attempt counts the runs of this task, variant, iteration and LLM config when it was re-run with --rerun;
+	// earlier attempts are kept in the attempts directory next to the results.
+	Attempt int `json:"attempt,omitempty"`
+
+	// RunID identifies the run that produced the result; its manifest is run.yaml in the output directory.
+	RunID string `json:"runID,omitempty"`
+
+	// Metrics are derived from the agent's trace, if it wrote one.
+	Metrics *TraceMetrics `json:"metrics,omitempty"`
+}
+
+// TraceMetrics describe how the agent went about a task, as recorded in its trace (see pkg/trace).
+type TraceMetrics struct {
+	LLMCalls  int `json:"llmCalls"`
+	ToolCalls int `json:"toolCalls"`
+
+	// KubectlVerbs are the distinct kubectl verbs the agent ran, e.g. "get" or "rollout restart".
+	KubectlVerbs []string `json:"kubectlVerbs,omitempty"`
+
+	// MutatingCommands counts the kubectl commands that change the cluster, excluding dry runs.
+	MutatingCommands int `json:"mutatingCommands"`
+
+	// ToolErrors counts the tool calls that returned an error or a non-zero exit code.
+	ToolErrors int `json:"toolErrors"`
+
+	// ModelSeconds is the time spent waiting for the model; ToolSeconds the time spent running tools.
+	ModelSeconds float64 `json:"modelSeconds"`
+	ToolSeconds  float64 `json:"toolSeconds"`
+
+	// SkippedEvents counts the trace events that were malformed or not recognized.
+	SkippedEvents int `json:"skippedEvents,omitempty"`
+}
+
+// Check is a single graded check of a task: an expectation, a verifier or an assertion.
+type Check struct {
+	Name string `json:"name"`
+	// Kind is the type of check, e.g. "contains", "set", "verifier" or "assertion".
+	Kind string `json:"kind"`
+	// Step is the 1-based script step the check belongs to, or 0 for task-level checks.
+	Step   int     `json:"step,omitempty"`
+	Weight float64 `json:"weight"`
+	// Score is the credit (0..1) earned by this check; pass/fail checks score 0 or 1.
+	Score   float64 `json:"score"`
+	Passed  bool    `json:"passed"`
+	Message string  `json:"message,omitempty"`
+}
+
+// Pass marks the check as passed with full credit.
+func (c *Check) Pass() {
+	c.Passed = true
+	c.Score = 1
+}
+
+// CheckWeight returns the weight of a check as declared in a task: 1 if it is not set.
+// A check with weight 0 is informational: it is recorded, but neither counts in the score nor
+// decides the result.
+func CheckWeight(weight *float64) float64 {
+	if weight == nil {
+		return 1
+	}
+	return *weight
+}
+
+// ComputeScore sets Score to the partial credit of the result: the weighted mean score of its checks,
+// whatever the result. A task succeeds when either its verifiers or its expectations pass, so a
+// successful result scores below 1 if the other checks did not pass. A result without weighted
+// checks scores 1 on success and 0 otherwise.
+func (r *TaskResult) ComputeScore() {
+	var total, weights float64
+	for _, check := range r.Checks {
+		total += check.Weight * check.Score
+		weights += check.Weight
+	}
+	switch {
+	case weights > 0:
+		r.Score = total / weights
+	case r.Result == "success":
+		r.Score = 1
+	default:
+		r.Score = 0
+	}
+}
+
+// SetScore compares the set of answers extracted from the agent output with the expected set.
+type SetScore struct {
+	Pattern string `json:"pattern"`
+
+	TruePositives  []string `json:"truePositives,omitempty"`
+	FalsePositives []string `json:"falsePositives,omitempty"`
+	FalseNegatives []string `json:"falseNegatives,omitempty"`
+
+	Precision float64 `json:"precision"`
+	Recall    float64 `json:"recall"`
+	F1        float64 `json:"f1"`
+}
+
+// JudgeVerdict is the grade a judge model gave a free-form answer.
+type JudgeVerdict struct {
+	// Judge identifies the judge, e.g. "gemini/gemini-2.5-flash" or "stub".
+	Judge  string `json:"judge"`
+	Rubric string `json:"rubric"`
+
+	// Step is the (1-based) script step the answer was given in, for step expectations.
+	Step int `json:"step,omitempty"`
+
+	Score     float64 `json:"score"`
+	Rationale string  `json:"rationale,omitempty"`
+}
+
+// AssertionResult is the outcome of a single declarative assertion about the cluster state.
+type AssertionResult struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"`
+}
+
+// StepResult is the outcome of the checks attached to a single script step.
+type StepResult struct {
+	// Step is the 1-based index of the script step.
+	Step     int       `json:"step"`
+	Result   string    `json:"result"`
+	Failures []Failure `json:"failures,omitempty"`
+
+	// Reason explains why the step's checks were not evaluated (see StepNotEvaluated) or, for an
+	// "error" result, why they could not be graded.
+	Reason string `json:"reason,omitempty"`
+}
+
+// StepNotEvaluated is the Result of a step whose checks could not be evaluated, because the
+// agent answered it together with other steps in a single turn (e.g. with --quiet).
+const StepNotEvaluated = "not-evaluated"
+
+// StepsEvaluated reports whether the checks of every step were evaluated.
+func (r *TaskResult) StepsEvaluated() bool {
+	for _, step := range r.Steps {
+		if step.Result == StepNotEvaluated {
+			return false
+		}
+	}
+	return true
+}
+
+// Clarification is a question the agent asked during a task and the simulated user's answer.
+type Clarification struct {
+	// Step is the 1-based index of the script step the question was asked in.
+	Step     int    `json:"step"`
+	Question string `json:"question"`
+	Answer   string `json:"answer"`
 }
 
 type Failure struct {
@@ -47,9 +246,61 @@ type LLMConfig struct {
 
 	McpClient bool `json:"mcpClient"`
 
+	// BaseURL is the provider's API endpoint, e.g. a vLLM server, when not the provider's default.
+	BaseURL string `json:"baseURL,omitempty"`
+
+	// Env is added to the agent's environment. Values may refer to the harness's environment as
+	// $VAR or ${VAR}, which is expanded when the agent starts, so secrets are not written to results.
+	Env map[string]string `json:"env,omitempty"`
+
+	// AgentArgs are appended to the agent's command line.
+	AgentArgs []string `json:"agentArgs,omitempty"`
+
+	// Agent is the agent benchmarked with this model; results written before agents were
+	// configurable have an empty agent, which is kubectl-ai.
+	Agent AgentConfig `json:"agent"`
+
 	// TODO: Maybe different styles of invocation, or different temperatures etc?
 }
 
+// AgentConfig selects and configures the agent under test (see pkg/agent).
+type AgentConfig struct {
+	// Type is the kind of agent: "kubectl-ai" (the default), "command" or "http".
+	Type string `json:"type,omitempty"`
+
+	// Name identifies the agent in results, e.g. to compare several command agents.
+	Name string `json:"name,omitempty"`
+
+	// Bin is the kubectl-ai binary, --agent-bin by default.
+	Bin string `json:"bin,omitempty"`
+
+	// Command is the argv of a command agent. Each argument, and each value of Env, is a Go template
+	// over the task and LLM config, e.g. "--model={{.Model}}".
+	Command []string          `json:"command,omitempty"`
+	Env     map[string]string `json:"env,omitempty"`
+
+	// URL is the endpoint of an HTTP agent.
+	URL string `json:"url,omitempty"`
+}
+
+// ResultSkipped is the Result of a task that was not run because the cluster does not meet its requirements.
+const ResultSkipped = "skipped"
+
+// ResultCancelled is the Result of a task that was interrupted (e.g. by Ctrl-C) before it completed.
+const ResultCancelled = "cancelled"
+
+// Cancel marks the task as cancelled, recording why in Error.
+func (r *TaskResult) Cancel(reason string) {
+	r.Result = ResultCancelled
+	r.Error = reason
+}
+
+// Skip marks the task as skipped, recording why.
+func (r *TaskResult) Skip(reasons ...string) {
+	r.Result = ResultSkipped
+	r.SkipReason = strings.Join(reasons, "; ")
+}
+
 // AddFailure is a helper for adding a formatted failure message; it also marks the test as failed
 func (r *TaskResult) AddFailure(msg string, args ...any) {
 	failure := Failure{
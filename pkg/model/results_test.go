@@ -0,0 +1,47 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package model
+
+import "testing"
+
+func TestComputeScore(t *testing.T) {
+	passed := Check{Weight: 1}
+	passed.Pass()
+	failed := Check{Weight: 1}
+	informational := Check{Weight: 0}
+	partial := Check{Weight: 2, Score: 0.5}
+
+	tests := []struct {
+		name   string
+		result TaskResult
+		want   float64
+	}{
+		{name: "success with every check passed", result: TaskResult{Result: "success", Checks: []Check{passed, passed}}, want: 1},
+		{name: "success with a failed check", result: TaskResult{Result: "success", Checks: []Check{passed, failed}}, want: 0.5},
+		{name: "failure with partial credit", result: TaskResult{Result: "fail", Checks: []Check{passed, partial, failed}}, want: 0.5},
+		{name: "informational checks do not count", result: TaskResult{Result: "fail", Checks: []Check{passed, informational}}, want: 1},
+		{name: "success without checks", result: TaskResult{Result: "success"}, want: 1},
+		{name: "success with only informational checks", result: TaskResult{Result: "success", Checks: []Check{informational}}, want: 1},
+		{name: "failure without checks", result: TaskResult{Result: "fail"}, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.result.ComputeScore()
+			if tt.result.Score != tt.want {
+				t.Errorf("Score = %v, want %v", tt.result.Score, tt.want)
+			}
+		})
+	}
+}
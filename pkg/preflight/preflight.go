@@ -0,0 +1,244 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preflight checks that a cluster meets the prerequisites of a task.
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster"
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
+)
+
+// Requirements are the prerequisites a task needs from the cluster, e.g.
+//
+//	requires:
+//	  apiGroups: ["metrics.k8s.io"]
+//	  storageClass:
+//	    allowVolumeExpansion: true
+//	  minNodes: 2
+//	  minKubernetesVersion: "1.29"
+type Requirements struct {
+	// APIGroups must be served by the cluster, e.g. "metrics.k8s.io".
+	APIGroups []string `json:"apiGroups,omitempty"`
+
+	// CRDs must be installed, by full name, e.g. "constrainttemplates.templates.gatekeeper.sh".
+	CRDs []string `json:"crds,omitempty"`
+
+	// StorageClass requires a storage class with the given features.
+	StorageClass *StorageClassRequirement `json:"storageClass,omitempty"`
+
+	// MinNodes is the minimum number of nodes.
+	MinNodes int `json:"minNodes,omitempty"`
+
+	// MinKubernetesVersion is the minimum server version, e.g. "1.29".
+	MinKubernetesVersion string `json:"minKubernetesVersion,omitempty"`
+}
+
+type StorageClassRequirement struct {
+	// Name is the storage class to check; if empty the default storage class is checked.
+	Name string `json:"name,omitempty"`
+
+	// AllowVolumeExpansion requires the storage class to allow resizing volumes.
+	AllowVolumeExpansion bool `json:"allowVolumeExpansion,omitempty"`
+}
+
+// Validate checks that the requirements are well formed.
+func (r *Requirements) Validate() error {
+	if r.MinNodes < 0 {
+		return fmt.Errorf("minNodes must not be negative")
+	}
+	if r.MinKubernetesVersion != "" {
+		if _, _, err := parseVersion(r.MinKubernetesVersion); err != nil {
+			return fmt.Errorf("minKubernetesVersion: %w", err)
+		}
+	}
+	return nil
+}
+
+// Unmet returns the requirements that clusters with the given capabilities cannot meet.
+// Capabilities that are unknown are assumed to be met; Check verifies them against the live cluster.
+func (r *Requirements) Unmet(caps cluster.Capabilities) []string {
+	var unmet []string
+	if r.MinNodes > 0 && caps.Nodes > 0 && caps.Nodes < r.MinNodes {
+		unmet = append(unmet, fmt.Sprintf("needs %d nodes, provider clusters have %d", r.MinNodes, caps.Nodes))
+	}
+	if r.StorageClass != nil && r.StorageClass.AllowVolumeExpansion && caps.VolumeExpansion != nil && !*caps.VolumeExpansion {
+		unmet = append(unmet, "needs a storage class that allows volume expansion, which provider clusters lack")
+	}
+	return unmet
+}
+
+// Check verifies the requirements against the cluster in kubeConfig, returning the unmet ones.
+// An error means the checks themselves could not be run.
+func (r *Requirements) Check(ctx context.Context, kubeConfig string) ([]string, error) {
+	client := verify.NewKubectl(kubeConfig)
+	var unmet []string
+
+	if len(r.APIGroups) > 0 {
+		out, err := verify.RunKubectl(ctx, kubeConfig, "api-versions")
+		if err != nil {
+			return nil, err
+		}
+		served := map[string]bool{}
+		for _, gv := range strings.Fields(string(out)) {
+			group, _, found := strings.Cut(gv, "/")
+			if !found {
+				group = "" // the core group is listed as just "v1"
+			}
+			served[group] = true
+		}
+		for _, group := range r.APIGroups {
+			if !served[group] {
+				unmet = append(unmet, fmt.Sprintf("API group %q is not served", group))
+			}
+		}
+	}
+
+	for _, crd := range r.CRDs {
+		objects, err := client.Get(ctx, "customresourcedefinition/"+crd, "", "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(objects) == 0 {
+			unmet = append(unmet, fmt.Sprintf("CRD %q is not installed", crd))
+		}
+	}
+
+	if r.StorageClass != nil {
+		reason, err := r.StorageClass.check(ctx, client)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			unmet = append(unmet, reason)
+		}
+	}
+
+	if r.MinNodes > 0 {
+		nodes, err := client.Get(ctx, "nodes", "", "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(nodes) < r.MinNodes {
+			unmet = append(unmet, fmt.Sprintf("needs %d nodes, cluster has %d", r.MinNodes, len(nodes)))
+		}
+	}
+
+	if r.MinKubernetesVersion != "" {
+		version, err := ServerVersion(ctx, kubeConfig)
+		if err != nil {
+			return nil, err
+		}
+		if !versionAtLeast(version, r.MinKubernetesVersion) {
+			unmet = append(unmet, fmt.Sprintf("needs Kubernetes %s or newer, cluster runs %s", r.MinKubernetesVersion, version))
+		}
+	}
+
+	return unmet, nil
+}
+
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+func (s *StorageClassRequirement) check(ctx context.Context, client verify.Client) (string, error) {
+	classes, err := client.Get(ctx, "storageclasses", "", "", "")
+	if err != nil {
+		return "", err
+	}
+	var class map[string]any
+	for _, c := range classes {
+		metadata, _ := c["metadata"].(map[string]any)
+		if s.Name != "" {
+			if metadata["name"] == s.Name {
+				class = c
+			}
+			continue
+		}
+		annotations, _ := metadata["annotations"].(map[string]any)
+		if annotations[defaultStorageClassAnnotation] == "true" {
+			class = c
+		}
+	}
+
+	description := "default storage class"
+	if s.Name != "" {
+		description = fmt.Sprintf("storage class %q", s.Name)
+	}
+	if class == nil {
+		return fmt.Sprintf("%s not found", description), nil
+	}
+	if s.AllowVolumeExpansion {
+		if allowed, _ := class["allowVolumeExpansion"].(bool); !allowed {
+			return fmt.Sprintf("%s does not allow volume expansion", description), nil
+		}
+	}
+	return "", nil
+}
+
+// ServerVersion returns the Kubernetes version of the cluster, e.g. "v1.29.3".
+func ServerVersion(ctx context.Context, kubeConfig string) (string, error) {
+	out, err := verify.RunKubectl(ctx, kubeConfig, "version", "-o", "json")
+	if err != nil {
+		return "", err
+	}
+	var version struct {
+		ServerVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	if err := json.Unmarshal(out, &version); err != nil {
+		return "", fmt.Errorf("parsing kubectl version output: %w", err)
+	}
+	if version.ServerVersion.GitVersion == "" {
+		return "", fmt.Errorf("kubectl version did not report a server version")
+	}
+	return version.ServerVersion.GitVersion, nil
+}
+
+// parseVersion parses the major and minor components of versions like "1.29" or "v1.29.3-gke.1".
+func parseVersion(v string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimPrefix(v, "v"), ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, fmt.Errorf("invalid version %q, expected <major>.<minor>", v)
+	}
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %w", v, err)
+	}
+	// Minor versions may carry a suffix such as "29+" on some providers.
+	minor, err := strconv.Atoi(strings.TrimRight(parts[1], "+"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid version %q: %w", v, err)
+	}
+	return major, minor, nil
+}
+
+// versionAtLeast reports whether version is at least min; unparsable versions are assumed to be new enough.
+func versionAtLeast(version, min string) bool {
+	major, minor, err := parseVersion(version)
+	if err != nil {
+		return true
+	}
+	minMajor, minMinor, err := parseVersion(min)
+	if err != nil {
+		return true
+	}
+	return slices.Compare([]int{major, minor}, []int{minMajor, minMinor}) >= 0
+}
@@ -0,0 +1,156 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// mutatingVerbs are the kubectl verbs that change the cluster. Verbs with subcommands that do are
+// listed with them, e.g. "rollout restart".
+var mutatingVerbs = []string{
+	"annotate", "apply", "autoscale", "cordon", "create", "delete", "drain", "edit", "expose", "label",
+	"patch", "replace", "run", "scale", "taint", "uncordon",
+	"rollout pause", "rollout restart", "rollout resume", "rollout undo",
+	"set env", "set image", "set resources", "set selector", "set serviceaccount", "set subject",
+}
+
+// verbsWithSubcommands are the kubectl verbs whose subcommand is part of the verb, e.g. "rollout status".
+var verbsWithSubcommands = []string{"auth", "certificate", "config", "rollout", "set"}
+
+// flagsWithValues are the common kubectl flags that take their value as the next argument.
+var flagsWithValues = []string{
+	"-n", "--namespace", "--context", "--kubeconfig", "--cluster", "--user", "-s", "--server", "--token", "--as", "--as-group",
+	"-o", "--output", "-l", "--selector",
+}
+
+// Metrics derives the metrics of a run from its transcript.
+func (t *Transcript) Metrics() model.TraceMetrics {
+	var m model.TraceMetrics
+	m.SkippedEvents = t.Skipped
+
+	verbs := make(map[string]bool)
+	var llmRequests []Entry
+	var toolCalls []Entry
+	var modelTime, toolTime time.Duration
+	for _, entry := range t.Entries {
+		switch entry.Kind {
+		case KindLLMRequest:
+			m.LLMCalls++
+			llmRequests = append(llmRequests, entry)
+		case KindModel:
+			if len(llmRequests) == 0 {
+				// A trace that records responses only.
+				m.LLMCalls++
+				continue
+			}
+			modelTime += elapsed(llmRequests[0].Time, entry.Time)
+			llmRequests = llmRequests[1:]
+		case KindToolCall:
+			m.ToolCalls++
+			toolCalls = append(toolCalls, entry)
+			for _, command := range kubectlCommands(entry.Command) {
+				verbs[command.verb] = true
+				if command.mutating() {
+					m.MutatingCommands++
+				}
+			}
+		case KindToolOutput:
+			if entry.Error != "" {
+				m.ToolErrors++
+			}
+			// Outputs answer calls by ID, or else in order.
+			i := slices.IndexFunc(toolCalls, func(call Entry) bool {
+				return entry.ToolCallID != "" && call.ToolCallID == entry.ToolCallID
+			})
+			if i < 0 {
+				if len(toolCalls) == 0 {
+					continue
+				}
+				i = 0
+			}
+			toolTime += elapsed(toolCalls[i].Time, entry.Time)
+			toolCalls = slices.Delete(toolCalls, i, i+1)
+		}
+	}
+
+	for verb := range verbs {
+		m.KubectlVerbs = append(m.KubectlVerbs, verb)
+	}
+	sort.Strings(m.KubectlVerbs)
+	m.ModelSeconds = modelTime.Seconds()
+	m.ToolSeconds = toolTime.Seconds()
+	return m
+}
+
+func elapsed(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+// kubectlCommand is a kubectl invocation found in a shell command.
+type kubectlCommand struct {
+	verb   string
+	dryRun bool
+}
+
+func (c kubectlCommand) mutating() bool {
+	return !c.dryRun && slices.Contains(mutatingVerbs, c.verb)
+}
+
+// kubectlCommands returns the kubectl invocations of a shell command, e.g. both of
+// "kubectl get pods -n web && kubectl delete pod web-0 -n web". Quoting is not interpreted.
+func kubectlCommands(shell string) []kubectlCommand {
+	var commands []kubectlCommand
+	separators := strings.NewReplacer("&&", "\n", "||", "\n", "|", "\n", ";", "\n")
+	for _, segment := range strings.Split(separators.Replace(shell), "\n") {
+		args := strings.Fields(segment)
+		if len(args) == 0 || path.Base(strings.Trim(args[0], `"'`)) != "kubectl" {
+			continue
+		}
+		var command kubectlCommand
+		var words []string
+		for i := 1; i < len(args); i++ {
+			arg := strings.Trim(args[i], `"'`)
+			if strings.HasPrefix(arg, "-") {
+				if strings.HasPrefix(arg, "--dry-run") && arg != "--dry-run=none" {
+					command.dryRun = true
+				}
+				if !strings.Contains(arg, "=") && slices.Contains(flagsWithValues, arg) {
+					i++
+				}
+				continue
+			}
+			words = append(words, arg)
+		}
+		if len(words) == 0 {
+			continue
+		}
+		command.verb = words[0]
+		if slices.Contains(verbsWithSubcommands, command.verb) && len(words) > 1 {
+			command.verb += " " + words[1]
+		}
+		commands = append(commands, command)
+	}
+	return commands
+}
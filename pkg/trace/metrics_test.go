@@ -0,0 +1,79 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	transcript, err := ParseFile("testdata/kubectl-ai.yaml")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	m := transcript.Metrics()
+
+	if m.LLMCalls != 4 || m.ToolCalls != 3 {
+		t.Errorf("got %d LLM calls and %d tool calls, want 4 and 3", m.LLMCalls, m.ToolCalls)
+	}
+	if want := []string{"delete", "get", "rollout restart", "set image"}; !reflect.DeepEqual(m.KubectlVerbs, want) {
+		t.Errorf("got kubectl verbs %v, want %v", m.KubectlVerbs, want)
+	}
+	// The delete is a dry run.
+	if m.MutatingCommands != 2 {
+		t.Errorf("got %d mutating commands, want 2", m.MutatingCommands)
+	}
+	if m.ToolErrors != 1 {
+		t.Errorf("got %d tool errors, want 1", m.ToolErrors)
+	}
+	if math.Abs(m.ModelSeconds-7) > 1e-6 || math.Abs(m.ToolSeconds-2.6) > 1e-6 {
+		t.Errorf("got %vs of model time and %vs of tool time, want 7s and 2.6s", m.ModelSeconds, m.ToolSeconds)
+	}
+	if m.SkippedEvents != 3 {
+		t.Errorf("got %d skipped events, want 3", m.SkippedEvents)
+	}
+}
+
+func TestKubectlCommands(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []kubectlCommand
+	}{
+		{"kubectl get pods -n web", []kubectlCommand{{verb: "get"}}},
+		{"kubectl -n web get pods", []kubectlCommand{{verb: "get"}}},
+		{"kubectl --namespace=web delete pod web-0", []kubectlCommand{{verb: "delete"}}},
+		{"kubectl -o yaml get deployment web", []kubectlCommand{{verb: "get"}}},
+		{"kubectl --output json get pods", []kubectlCommand{{verb: "get"}}},
+		{"kubectl -l app=web delete pods", []kubectlCommand{{verb: "delete"}}},
+		{"kubectl --selector app=web rollout restart deployment", []kubectlCommand{{verb: "rollout restart"}}},
+		{"kubectl rollout -n web status deployment/web", []kubectlCommand{{verb: "rollout status"}}},
+		{"kubectl apply -f pod.yaml --dry-run=server", []kubectlCommand{{verb: "apply", dryRun: true}}},
+		{"kubectl apply -f pod.yaml --dry-run=none", []kubectlCommand{{verb: "apply"}}},
+		{"/usr/local/bin/kubectl scale deploy web --replicas=2", []kubectlCommand{{verb: "scale"}}},
+		{
+			"kubectl get pods -n web | grep web && kubectl delete pod web-0 -n web; echo done",
+			[]kubectlCommand{{verb: "get"}, {verb: "delete"}},
+		},
+		{"helm list", nil},
+		{"kubectl", nil},
+	}
+	for _, tt := range tests {
+		if got := kubectlCommands(tt.shell); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("kubectlCommands(%q) = %+v, want %+v", tt.shell, got, tt.want)
+		}
+	}
+}
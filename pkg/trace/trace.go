@@ -0,0 +1,274 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trace reads the traces agents write with --trace-path (the kubectl-ai journal: a stream
+// of YAML documents, each an event with a timestamp, an action and a payload) into a normalized
+// transcript, and derives metrics of the run from it.
+//
+// kubectl-ai records its calls to the model as the HTTP traffic of the LLM client ("http.request",
+// then "http.response" or "http.error"), the tools it runs as "tool-request" and "tool-response", and
+// what it shows the user as "ui.render", which is not part of the transcript.
+//
+// The journal format is not a stable interface, so parsing is tolerant: actions are classified by
+// the words they contain, payload keys are matched case-insensitively, and events that cannot be
+// parsed or classified are skipped rather than failing the whole trace.
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// EntryKind is the kind of a transcript entry.
+type EntryKind string
+
+const (
+	// KindUser is a message from the user: a prompt or an answer to a question.
+	KindUser EntryKind = "user"
+	// KindLLMRequest is a call to the model.
+	KindLLMRequest EntryKind = "llm-request"
+	// KindModel is a response of the model.
+	KindModel EntryKind = "model"
+	// KindToolCall is a tool the model asked to run.
+	KindToolCall EntryKind = "tool-call"
+	// KindToolOutput is the output of a tool call.
+	KindToolOutput EntryKind = "tool-output"
+)
+
+// Entry is an event of the transcript.
+type Entry struct {
+	Kind EntryKind
+	// Time is when the event was recorded; zero if the trace did not say.
+	Time time.Time
+
+	// Text is the message of a user or model entry, or the output of a tool.
+	Text string
+
+	// ToolCallID, Tool and Arguments describe a tool call; Command is its shell command, if any.
+	// Tool outputs carry the ID (and, when the trace records it, the name) of the call they answer.
+	ToolCallID string
+	Tool       string
+	Arguments  map[string]any
+	Command    string
+
+	// Error is the error a tool returned.
+	Error string
+}
+
+// Transcript is the normalized content of a trace.
+type Transcript struct {
+	Entries []Entry
+
+	// Skipped counts the events that could not be parsed or were not recognized.
+	Skipped int
+}
+
+// ParseFile parses the trace at path.
+func ParseFile(path string) (*Transcript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses a trace. Only reading it can fail; malformed events are counted in Skipped.
+func Parse(r io.Reader) (*Transcript, error) {
+	t := &Transcript{}
+	var doc strings.Builder
+	flush := func() {
+		if strings.TrimSpace(doc.String()) != "" {
+			t.add([]byte(doc.String()))
+		}
+		doc.Reset()
+	}
+
+	scanner := bufio.NewScanner(r)
+	// Tool outputs (e.g. a large kubectl get -o yaml) make for long lines.
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimRight(line, " \t\r") == "---" {
+			flush()
+			continue
+		}
+		doc.WriteString(line)
+		doc.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading trace: %w", err)
+	}
+	flush()
+	return t, nil
+}
+
+// add appends the entry for an event, or counts it as skipped.
+func (t *Transcript) add(doc []byte) {
+	var event map[string]any
+	if err := yaml.Unmarshal(doc, &event); err != nil || event == nil {
+		t.Skipped++
+		return
+	}
+	action := stringField(event, "action", "type", "kind", "event")
+	payload, _ := field(event, "payload", "data", "content")
+
+	entry := Entry{Kind: classify(action, payload)}
+	if entry.Kind == "" {
+		t.Skipped++
+		return
+	}
+	if ts := stringField(event, "timestamp", "time", "ts"); ts != "" {
+		entry.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+
+	switch entry.Kind {
+	case KindUser, KindModel:
+		entry.Text = text(payload, "text", "message", "content", "response", "query", "prompt")
+	case KindToolCall:
+		call := asMap(payload)
+		entry.ToolCallID = stringField(call, "id", "callID", "toolCallID")
+		entry.Tool = stringField(call, "name", "tool", "function", "functionName")
+		if args, ok := field(call, "arguments", "args", "input", "parameters"); ok {
+			entry.Arguments = asMap(args)
+			if entry.Arguments == nil {
+				// Some providers encode the arguments as a JSON string.
+				json.Unmarshal([]byte(text(args)), &entry.Arguments)
+			}
+		}
+		entry.Command = stringField(entry.Arguments, "command", "cmd")
+	case KindToolOutput:
+		output := asMap(payload)
+		// The output may record the call it answers.
+		call := output
+		if request, ok := field(output, "request", "call", "toolCall"); ok {
+			call = asMap(request)
+		}
+		entry.ToolCallID = stringField(call, "id", "callID", "toolCallID")
+		entry.Tool = stringField(call, "name", "tool", "function", "functionName")
+
+		result := payload
+		if response, ok := field(output, "response", "result", "output"); ok {
+			result = response
+		}
+		entry.Text = text(result, "stdout", "output", "text", "content")
+		entry.Error = toolError(output)
+		if entry.Error == "" {
+			entry.Error = toolError(asMap(result))
+		}
+	}
+	t.Entries = append(t.Entries, entry)
+}
+
+// classify returns the kind of entry an event action describes, or "" for events (e.g. UI
+// rendering) that are not part of the transcript.
+func classify(action string, payload any) EntryKind {
+	a := strings.ToLower(action)
+	isTool := strings.Contains(a, "tool") || strings.Contains(a, "function")
+	isLLM := containsAny(a, "llm", "chat", "model", "gollm", "completion", "http")
+	switch {
+	case isTool && containsAny(a, "response", "result", "output"):
+		return KindToolOutput
+	case isTool && containsAny(a, "request", "call"):
+		return KindToolCall
+	case isLLM && strings.Contains(a, "request"):
+		return KindLLMRequest
+	case isLLM:
+		return KindModel
+	case containsAny(a, "user", "query", "prompt"):
+		return KindUser
+	}
+	return ""
+}
+
+// toolError returns the error recorded in a tool output: an error message, or a non-zero exit code.
+func toolError(output map[string]any) string {
+	if msg := stringField(output, "error", "err", "errorMessage"); msg != "" && msg != "null" && msg != "<nil>" {
+		return msg
+	}
+	if code, ok := field(output, "exitCode", "exit_code", "exitStatus"); ok {
+		if n, ok := code.(float64); ok && n != 0 {
+			if stderr := stringField(output, "stderr"); stderr != "" {
+				return stderr
+			}
+			return fmt.Sprintf("exit code %d", int(n))
+		}
+	}
+	return ""
+}
+
+// field returns the value of the first of keys in m, matching keys case-insensitively and
+// ignoring "_" and "-", so that e.g. "exit_code" matches "exitCode".
+func field(m map[string]any, keys ...string) (any, bool) {
+	for _, key := range keys {
+		for k, v := range m {
+			if normalizeKey(k) == normalizeKey(key) {
+				return v, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func stringField(m map[string]any, keys ...string) string {
+	v, ok := field(m, keys...)
+	if !ok || v == nil {
+		return ""
+	}
+	return text(v)
+}
+
+func normalizeKey(k string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(k))
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+// text returns v as text: strings as they are, the first of keys for maps, and anything else as JSON.
+func text(v any, keys ...string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any:
+		if inner, ok := field(v, keys...); ok {
+			return text(inner)
+		}
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+func containsAny(s string, substrs ...string) bool {
+	for _, substr := range substrs {
+		if strings.Contains(s, substr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFile(t *testing.T) {
+	transcript, err := ParseFile("testdata/kubectl-ai.yaml")
+	if err != nil {
+		t.Fatalf("ParseFile failed: %v", err)
+	}
+	// The UI renders and the malformed last event.
+	if transcript.Skipped != 3 {
+		t.Errorf("skipped %d events, want 3", transcript.Skipped)
+	}
+
+	var kinds []EntryKind
+	for _, entry := range transcript.Entries {
+		kinds = append(kinds, entry.Kind)
+	}
+	want := []EntryKind{
+		KindLLMRequest, KindModel, KindToolCall, KindToolOutput,
+		KindLLMRequest, KindModel, KindToolCall, KindToolOutput, KindToolCall, KindToolOutput,
+		KindLLMRequest, KindModel, KindLLMRequest, KindModel,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("got entries %v, want %v", kinds, want)
+	}
+
+	call := transcript.Entries[2]
+	if call.ToolCallID != "call-1" || call.Tool != "kubectl" || call.Command != "kubectl get pods -n web -o wide" {
+		t.Errorf("got tool call %q %q %q, want call-1 kubectl \"kubectl get pods -n web -o wide\"", call.ToolCallID, call.Tool, call.Command)
+	}
+	if call.Time.IsZero() {
+		t.Error("tool call has no time")
+	}
+	if output := transcript.Entries[3]; !strings.Contains(output.Text, "ImagePullBackOff") || output.Error != "" {
+		t.Errorf("got tool output %q with error %q, want the pods and no error", output.Text, output.Error)
+	}
+	if output := transcript.Entries[9]; output.Error != "exit status 1" {
+		t.Errorf("got tool error %q, want \"exit status 1\"", output.Error)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		action string
+		want   EntryKind
+	}{
+		{"http.request", KindLLMRequest},
+		{"http.response", KindModel},
+		{"http.error", KindModel},
+		{"tool-request", KindToolCall},
+		{"tool-response", KindToolOutput},
+		{"ui.render", ""},
+		{"llm-request", KindLLMRequest},
+		{"llm-response", KindModel},
+		{"function_call", KindToolCall},
+		{"function_result", KindToolOutput},
+		{"user-query", KindUser},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := classify(tt.action, nil); got != tt.want {
+			t.Errorf("classify(%q) = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestParseTolerant(t *testing.T) {
+	trace := `---
+action: Tool_Call
+payload:
+  ID: "1"
+  Function: bash
+  Args: '{"cmd": "kubectl get ns"}'
+---
+action: tool_result
+payload:
+  exitCode: 2
+  stderr: boom
+---
+- not
+- an event
+`
+	transcript, err := Parse(strings.NewReader(trace))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if transcript.Skipped != 1 || len(transcript.Entries) != 2 {
+		t.Fatalf("got %d entries and %d skipped, want 2 and 1", len(transcript.Entries), transcript.Skipped)
+	}
+	if call := transcript.Entries[0]; call.ToolCallID != "1" || call.Tool != "bash" || call.Command != "kubectl get ns" {
+		t.Errorf("got tool call %q %q %q, want 1 bash \"kubectl get ns\"", call.ToolCallID, call.Tool, call.Command)
+	}
+	if output := transcript.Entries[1]; output.Error != "boom" {
+		t.Errorf("got tool error %q, want \"boom\"", output.Error)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package usersim simulates the user answering an agent's clarifying questions.
+package usersim
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/llm"
+)
+
+// DefaultMaxRounds is the default limit on the number of questions answered per task.
+const DefaultMaxRounds = 3
+
+// DefaultFallback is the reply when no rule or fact answers a question.
+const DefaultFallback = "I'm not sure. Please use your best judgement."
+
+// defaultQuestionPattern matches output whose last paragraph ends with a question mark.
+var defaultQuestionPattern = regexp.MustCompile(`\?\s*$`)
+
+// Spec describes what the simulated user knows and how they answer, e.g.
+//
+//	user:
+//	  facts:
+//	  - The app listens on port 8080.
+//	  responses:
+//	  - match: "(?i)which namespace"
+//	    reply: "It's in webapp-frontend."
+type Spec struct {
+	// Facts are things the user knows; the simulator answers questions from them.
+	Facts []string `json:"facts,omitempty"`
+
+	// Responses are canned replies, tried in order before the facts are consulted.
+	Responses []Response `json:"responses,omitempty"`
+
+	// Fallback is the reply when nothing else answers the question (default DefaultFallback).
+	Fallback string `json:"fallback,omitempty"`
+
+	// QuestionPattern is a regex matched against the last paragraph of the agent's turn to detect
+	// a question (default: the paragraph ends with "?").
+	QuestionPattern string `json:"questionPattern,omitempty"`
+
+	// MaxRounds limits the number of questions answered over the whole task (default DefaultMaxRounds).
+	MaxRounds int `json:"maxRounds,omitempty"`
+}
+
+// Response is a canned reply to questions matching a regex.
+type Response struct {
+	Match string `json:"match"`
+	Reply string `json:"reply"`
+}
+
+// Validate checks that the spec is well formed and its regexes compile.
+func (s *Spec) Validate() error {
+	if s.QuestionPattern != "" {
+		if _, err := regexp.Compile(s.QuestionPattern); err != nil {
+			return fmt.Errorf("questionPattern: %w", err)
+		}
+	}
+	for i, r := range s.Responses {
+		if r.Match == "" || r.Reply == "" {
+			return fmt.Errorf("responses[%d]: match and reply are required", i)
+		}
+		if _, err := regexp.Compile(r.Match); err != nil {
+			return fmt.Errorf("responses[%d].match: %w", i, err)
+		}
+	}
+	if s.MaxRounds < 0 {
+		return fmt.Errorf("maxRounds must not be negative")
+	}
+	return nil
+}
+
+// Simulator answers questions on behalf of the user described by a Spec.
+type Simulator struct {
+	spec            *Spec
+	model           llm.Client
+	questionPattern *regexp.Regexp
+	responses       []*regexp.Regexp
+
+	// request is what the user originally asked for, given to the model as context.
+	request string
+
+	rounds int
+}
+
+// New returns a simulator for spec. Questions that no canned response matches are answered by
+// model from the facts; if model is nil, a local stub picks the fact sharing the most words with the question.
+func New(spec *Spec, request string, model llm.Client) (*Simulator, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	s := &Simulator{spec: spec, model: model, request: request, questionPattern: defaultQuestionPattern}
+	if spec.QuestionPattern != "" {
+		s.questionPattern = regexp.MustCompile(spec.QuestionPattern)
+	}
+	for _, r := range spec.Responses {
+		s.responses = append(s.responses, regexp.MustCompile(r.Match))
+	}
+	return s, nil
+}
+
+// Question returns the question the agent asked at the end of output, if any,
+// and if the simulator has rounds left to answer it.
+func (s *Simulator) Question(output string) (string, bool) {
+	maxRounds := s.spec.MaxRounds
+	if maxRounds == 0 {
+		maxRounds = DefaultMaxRounds
+	}
+	if s.rounds >= maxRounds {
+		return "", false
+	}
+	question := lastParagraph(output)
+	if question == "" || !s.questionPattern.MatchString(question) {
+		return "", false
+	}
+	return question, true
+}
+
+// Answer replies to a question and counts it as a clarification round.
+func (s *Simulator) Answer(ctx context.Context, question string) (string, error) {
+	s.rounds++
+	for i, re := range s.responses {
+		if re.MatchString(question) {
+			return s.spec.Responses[i].Reply, nil
+		}
+	}
+	if s.model != nil {
+		return s.model.Generate(ctx, s.systemPrompt(), question)
+	}
+	if fact := bestFact(s.spec.Facts, question); fact != "" {
+		return fact, nil
+	}
+	return s.fallback(), nil
+}
+
+func (s *Simulator) fallback() string {
+	if s.spec.Fallback != "" {
+		return s.spec.Fallback
+	}
+	return DefaultFallback
+}
+
+func (s *Simulator) systemPrompt() string {
+	var b strings.Builder
+	b.WriteString("You are a Kubernetes user who asked an AI assistant for help. The assistant is asking you a clarifying question.\n")
+	fmt.Fprintf(&b, "Your original request was:\n%s\n\n", s.request)
+	b.WriteString("Facts you know:\n")
+	for _, fact := range s.spec.Facts {
+		fmt.Fprintf(&b, "- %s\n", fact)
+	}
+	b.WriteString("\nAnswer in one or two sentences, as the user, using only these facts. ")
+	b.WriteString("Do not solve the task or suggest commands yourself. ")
+	fmt.Fprintf(&b, "If the facts do not answer the question, reply exactly: %s\n", s.fallback())
+	return b.String()
+}
+
+// lastParagraph returns the last block of non-blank lines of s.
+func lastParagraph(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	start := len(lines)
+	for start > 0 && strings.TrimSpace(lines[start-1]) != "" {
+		start--
+	}
+	return strings.TrimSpace(strings.Join(lines[start:], "\n"))
+}
+
+// bestFact returns the fact sharing the most (non-trivial) words with question, or "" if none do.
+func bestFact(facts []string, question string) string {
+	questionWords := words(question)
+	best, bestScore := "", 0
+	for _, fact := range facts {
+		score := 0
+		for word := range words(fact) {
+			if questionWords[word] {
+				score++
+			}
+		}
+		if score > bestScore {
+			best, bestScore = fact, score
+		}
+	}
+	return best
+}
+
+// words returns the set of lowercased words of s longer than three letters.
+func words(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(w) > 3 {
+			set[w] = true
+		}
+	}
+	return set
+}
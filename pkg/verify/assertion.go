@@ -0,0 +1,282 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package verify evaluates declarative assertions about cluster state.
+package verify
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// DefaultTimeout is how long an assertion is polled when it does not set a timeout.
+const DefaultTimeout = 60 * time.Second
+
+// PollInterval is how often a failing assertion is re-evaluated.
+const PollInterval = 2 * time.Second
+
+// minCheckTimeout bounds a check of an assertion whose timeout has expired, or nearly, by the time it is reached.
+const minCheckTimeout = 10 * time.Second
+
+// Assertion is a single check against the cluster, e.g.
+//
+//	verify:
+//	- resource: deployment/web-app
+//	  namespace: scale-test
+//	  jsonPath: '{.status.availableReplicas}'
+//	  equals: "2"
+//
+// The kind of check is determined by which fields are set: absent, count,
+// condition or jsonPath; if none are set the resource must exist.
+// When resource names a kind rather than a single object, field and condition
+// checks must hold for every matching object (and at least one must match).
+type Assertion struct {
+	// Name is an optional human readable description.
+	Name string `json:"name,omitempty"`
+
+	// Resource is "kind" or "kind/name", as accepted by kubectl get.
+	Resource  string `json:"resource"`
+	Namespace string `json:"namespace,omitempty"`
+	Selector  string `json:"selector,omitempty"`
+
+	// FieldSelector filters objects by field, e.g. "reason=BackOff" for events.
+	FieldSelector string `json:"fieldSelector,omitempty"`
+
+	// Absent requires that no matching object exists.
+	Absent bool `json:"absent,omitempty"`
+
+	// Count requires exactly this many matching objects.
+	Count *int `json:"count,omitempty"`
+
+	// Condition requires status.conditions to contain this type with status ConditionStatus.
+	Condition       string `json:"condition,omitempty"`
+	ConditionStatus string `json:"conditionStatus,omitempty"`
+
+	// JSONPath selects a field that must equal Equals or match the regex Matches (one of them is required).
+	JSONPath string `json:"jsonPath,omitempty"`
+	Equals   string `json:"equals,omitempty"`
+	Matches  string `json:"matches,omitempty"`
+
+	// Timeout is how long to wait for the assertion to hold (default 60s).
+	Timeout string `json:"timeout,omitempty"`
+
+	// Weight is the relative weight of this assertion in the task score (default 1).
+	Weight *float64 `json:"weight,omitempty"`
+}
+
+// Description returns the assertion name, or a generated summary of the check.
+func (a *Assertion) Description() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	target := a.Resource
+	if a.Namespace != "" {
+		target += " -n " + a.Namespace
+	}
+	if a.Selector != "" {
+		target += " -l " + a.Selector
+	}
+	if a.FieldSelector != "" {
+		target += " --field-selector " + a.FieldSelector
+	}
+	switch {
+	case a.Absent:
+		return fmt.Sprintf("%s is absent", target)
+	case a.Count != nil:
+		return fmt.Sprintf("%s count is %d", target, *a.Count)
+	case a.Condition != "":
+		return fmt.Sprintf("%s has condition %s=%s", target, a.Condition, a.conditionStatus())
+	case a.JSONPath != "" && a.Matches != "":
+		return fmt.Sprintf("%s %s matches %q", target, a.JSONPath, a.Matches)
+	case a.JSONPath != "":
+		return fmt.Sprintf("%s %s equals %q", target, a.JSONPath, a.Equals)
+	default:
+		return fmt.Sprintf("%s exists", target)
+	}
+}
+
+func (a *Assertion) conditionStatus() string {
+	if a.ConditionStatus == "" {
+		return "True"
+	}
+	return a.ConditionStatus
+}
+
+// Validate checks that the assertion is well formed.
+func (a *Assertion) Validate() error {
+	if a.Resource == "" {
+		return fmt.Errorf("resource is required")
+	}
+	kinds := 0
+	for _, set := range []bool{a.Absent, a.Count != nil, a.Condition != "", a.JSONPath != ""} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return fmt.Errorf("only one of absent, count, condition or jsonPath may be set")
+	}
+	if a.JSONPath != "" {
+		if _, err := CompileJSONPath(a.JSONPath); err != nil {
+			return err
+		}
+		if a.Equals != "" && a.Matches != "" {
+			return fmt.Errorf("only one of equals or matches may be set")
+		}
+		if a.Equals == "" && a.Matches == "" {
+			return fmt.Errorf("jsonPath requires equals or matches")
+		}
+	} else if a.Equals != "" || a.Matches != "" {
+		return fmt.Errorf("equals and matches require jsonPath")
+	}
+	if a.Matches != "" {
+		if _, err := regexp.Compile(a.Matches); err != nil {
+			return fmt.Errorf("compiling matches %q: %w", a.Matches, err)
+		}
+	}
+	if a.Timeout != "" {
+		if _, err := time.ParseDuration(a.Timeout); err != nil {
+			return fmt.Errorf("parsing timeout %q: %w", a.Timeout, err)
+		}
+	}
+	if a.Weight != nil && *a.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+	return nil
+}
+
+// Evaluate checks each assertion in turn, polling until it holds or its timeout expires.
+// Timeouts count from the start of the evaluation, so that the assertions wait for the cluster
+// together: a failing task waits for the longest timeout, not for the sum of them.
+func Evaluate(ctx context.Context, client Client, assertions []Assertion) []model.AssertionResult {
+	start := time.Now()
+	var results []model.AssertionResult
+	for i := range assertions {
+		a := &assertions[i]
+		result := model.AssertionResult{Assertion: a.Description()}
+		if err := a.Validate(); err != nil {
+			result.Message = fmt.Sprintf("invalid assertion: %v", err)
+			results = append(results, result)
+			continue
+		}
+		if err := a.wait(ctx, client, start); err != nil {
+			result.Message = err.Error()
+		} else {
+			result.Passed = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// wait polls the assertion until it holds or its timeout, counted from start, expires.
+// It is checked at least once, even if the timeout has already expired.
+func (a *Assertion) wait(ctx context.Context, client Client, start time.Time) error {
+	timeout := DefaultTimeout
+	if a.Timeout != "" {
+		timeout, _ = time.ParseDuration(a.Timeout)
+	}
+	deadline := start.Add(timeout)
+
+	for {
+		checkDeadline := deadline
+		if time.Until(deadline) < minCheckTimeout {
+			checkDeadline = time.Now().Add(minCheckTimeout)
+		}
+		checkCtx, cancel := context.WithDeadline(ctx, checkDeadline)
+		err := a.check(checkCtx, client)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		wait := min(PollInterval, time.Until(deadline))
+		if wait <= 0 {
+			return fmt.Errorf("not satisfied after %v: %w", timeout, err)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("not satisfied after %v: %w", timeout, err)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// check evaluates the assertion once against the current cluster state.
+func (a *Assertion) check(ctx context.Context, client Client) error {
+	objects, err := client.Get(ctx, a.Resource, a.Namespace, a.Selector, a.FieldSelector)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case a.Absent:
+		if len(objects) != 0 {
+			return fmt.Errorf("found %d matching objects", len(objects))
+		}
+		return nil
+	case a.Count != nil:
+		if len(objects) != *a.Count {
+			return fmt.Errorf("found %d matching objects, want %d", len(objects), *a.Count)
+		}
+		return nil
+	}
+
+	if len(objects) == 0 {
+		return fmt.Errorf("no matching objects found")
+	}
+
+	switch {
+	case a.Condition != "":
+		path := conditionStatusPath(a.Condition)
+		for _, obj := range objects {
+			if got := path.Evaluate(obj); got != a.conditionStatus() {
+				return fmt.Errorf("%s: condition %s is %q, want %q", objectName(obj), a.Condition, got, a.conditionStatus())
+			}
+		}
+	case a.JSONPath != "":
+		path, err := CompileJSONPath(a.JSONPath)
+		if err != nil {
+			return err
+		}
+		var re *regexp.Regexp
+		if a.Matches != "" {
+			re = regexp.MustCompile(a.Matches)
+		}
+		for _, obj := range objects {
+			got := path.Evaluate(obj)
+			if re != nil {
+				if !re.MatchString(got) {
+					return fmt.Errorf("%s: %s is %q, which does not match %q", objectName(obj), a.JSONPath, got, a.Matches)
+				}
+			} else if got != a.Equals {
+				return fmt.Errorf("%s: %s is %q, want %q", objectName(obj), a.JSONPath, got, a.Equals)
+			}
+		}
+	}
+	return nil
+}
+
+func objectName(obj map[string]any) string {
+	kind, _ := obj["kind"].(string)
+	name := ""
+	if metadata, ok := obj["metadata"].(map[string]any); ok {
+		name, _ = metadata["name"].(string)
+	}
+	return strings.ToLower(kind) + "/" + name
+}
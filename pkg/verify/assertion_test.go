@@ -0,0 +1,118 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClient returns the same objects for every query.
+type fakeClient struct {
+	objects []map[string]any
+}
+
+func (c *fakeClient) Get(ctx context.Context, resource, namespace, selector, fieldSelector string) ([]map[string]any, error) {
+	return c.objects, nil
+}
+
+func TestEvaluate(t *testing.T) {
+	client := &fakeClient{objects: []map[string]any{{
+		"kind":     "Pod",
+		"metadata": map[string]any{"name": "web"},
+		"status": map[string]any{
+			"phase":      "Running",
+			"conditions": []any{map[string]any{"type": "Ready", "status": "True"}},
+		},
+	}}}
+	one := 1
+	assertions := []Assertion{
+		{Resource: "pods", JSONPath: "{.status.phase}", Equals: "Running"},
+		{Resource: "pods", JSONPath: "{.status.phase}", Matches: "^Run"},
+		{Resource: "pods", Condition: "Ready"},
+		{Resource: "pods", Count: &one},
+		{Resource: "pods", JSONPath: "{.status.phase}", Equals: "Pending", Timeout: "10ms"},
+		{Resource: "pods", Condition: "Ready", ConditionStatus: "False", Timeout: "10ms"},
+		{Resource: "pods", Absent: true, Timeout: "10ms"},
+		{Resource: "pods", Absent: true, Count: &one},
+	}
+	want := []bool{true, true, true, true, false, false, false, false}
+
+	results := Evaluate(context.Background(), client, assertions)
+	if len(results) != len(assertions) {
+		t.Fatalf("got %d results, want %d", len(results), len(assertions))
+	}
+	for i, result := range results {
+		if result.Passed != want[i] {
+			t.Errorf("assertion %q: passed = %v, want %v (%s)", result.Assertion, result.Passed, want[i], result.Message)
+		}
+	}
+}
+
+func TestEvaluateSharesDeadline(t *testing.T) {
+	client := &fakeClient{}
+	const timeout = 300 * time.Millisecond
+	var assertions []Assertion
+	for range 3 {
+		assertions = append(assertions, Assertion{Resource: "pods", Timeout: timeout.String()})
+	}
+
+	start := time.Now()
+	results := Evaluate(context.Background(), client, assertions)
+	elapsed := time.Since(start)
+
+	for _, result := range results {
+		if result.Passed {
+			t.Errorf("assertion %q passed, want it to fail", result.Assertion)
+		}
+	}
+	// Each assertion is checked, but together they wait for one timeout rather than three.
+	if elapsed >= 2*timeout {
+		t.Errorf("Evaluate took %v, want less than %v", elapsed, 2*timeout)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	one := 1
+	negative := -1.0
+	tests := []struct {
+		assertion Assertion
+		wantErr   string
+	}{
+		{assertion: Assertion{Resource: "pods"}},
+		{assertion: Assertion{Resource: "pods", JSONPath: "{.status.phase}", Equals: "Running"}},
+		{assertion: Assertion{Resource: "pods", JSONPath: "{.status.phase}", Matches: "^Run"}},
+		{assertion: Assertion{Resource: "pods", Condition: "Ready", Timeout: "2m"}},
+		{assertion: Assertion{JSONPath: "{.status.phase}", Equals: "Running"}, wantErr: "resource is required"},
+		{assertion: Assertion{Resource: "pods", JSONPath: "{.status.phase}"}, wantErr: "jsonPath requires equals or matches"},
+		{assertion: Assertion{Resource: "pods", JSONPath: "{.status.phase}", Equals: "a", Matches: "a"}, wantErr: "only one of equals or matches"},
+		{assertion: Assertion{Resource: "pods", Equals: "Running"}, wantErr: "equals and matches require jsonPath"},
+		{assertion: Assertion{Resource: "pods", Absent: true, Count: &one}, wantErr: "only one of absent"},
+		{assertion: Assertion{Resource: "pods", JSONPath: "{.status.phase}", Matches: "("}, wantErr: "compiling matches"},
+		{assertion: Assertion{Resource: "pods", Timeout: "soon"}, wantErr: "parsing timeout"},
+		{assertion: Assertion{Resource: "pods", Weight: &negative}, wantErr: "weight must not be negative"},
+	}
+	for _, tt := range tests {
+		err := tt.assertion.Validate()
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s: Validate failed: %v", tt.assertion.Description(), err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s: Validate = %v, want an error containing %q", tt.assertion.Description(), err, tt.wantErr)
+		}
+	}
+}
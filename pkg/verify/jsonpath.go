@@ -0,0 +1,279 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONPath is a compiled subset of the kubectl JSONPath syntax.
+// It supports field access (.a.b), indexes ([0]), wildcards ([*]) and
+// simple equality filters ([?(@.type=="Ready")] and !=).
+type JSONPath struct {
+	expr     string
+	segments []segment
+}
+
+type segmentKind int
+
+const (
+	segmentField segmentKind = iota
+	segmentIndex
+	segmentWildcard
+	segmentFilter
+)
+
+type segment struct {
+	kind  segmentKind
+	field string
+	index int
+
+	// filter fields
+	filterPath []string
+	filterOp   string
+	filterVal  string
+}
+
+// CompileJSONPath parses a JSONPath expression such as '{.status.availableReplicas}'.
+func CompileJSONPath(expr string) (*JSONPath, error) {
+	s := strings.TrimSpace(expr)
+	s = strings.TrimPrefix(s, "{")
+	s = strings.TrimSuffix(s, "}")
+	s = strings.TrimPrefix(s, "$")
+
+	p := &JSONPath{expr: expr}
+	for len(s) > 0 {
+		switch s[0] {
+		case '.':
+			s = s[1:]
+			end := strings.IndexAny(s, ".[")
+			if end == -1 {
+				end = len(s)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid jsonpath %q: empty field name", expr)
+			}
+			p.segments = append(p.segments, segment{kind: segmentField, field: s[:end]})
+			s = s[end:]
+		case '[':
+			end := closingBracket(s)
+			if end == -1 {
+				return nil, fmt.Errorf("invalid jsonpath %q: unterminated '['", expr)
+			}
+			inner := s[1:end]
+			s = s[end+1:]
+			seg, err := parseBracket(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid jsonpath %q: %w", expr, err)
+			}
+			p.segments = append(p.segments, seg)
+		default:
+			return nil, fmt.Errorf("invalid jsonpath %q: unexpected %q", expr, s[0])
+		}
+	}
+	return p, nil
+}
+
+// closingBracket returns the index of the ']' closing the bracket s starts with, skipping
+// quoted strings (e.g. in [?(@.name=="a]b")]), or -1.
+func closingBracket(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			return i
+		}
+	}
+	return -1
+}
+
+// conditionStatusPath returns the path of the status of the condition of type conditionType,
+// {.status.conditions[?(@.type=="<conditionType>")].status}, built directly so that the type
+// needs no quoting.
+func conditionStatusPath(conditionType string) *JSONPath {
+	return &JSONPath{
+		expr: fmt.Sprintf("{.status.conditions[?(@.type==%q)].status}", conditionType),
+		segments: []segment{
+			{kind: segmentField, field: "status"},
+			{kind: segmentField, field: "conditions"},
+			{kind: segmentFilter, filterPath: []string{"type"}, filterOp: "==", filterVal: conditionType},
+			{kind: segmentField, field: "status"},
+		},
+	}
+}
+
+func parseBracket(inner string) (segment, error) {
+	if inner == "*" {
+		return segment{kind: segmentWildcard}, nil
+	}
+	if strings.HasPrefix(inner, "?(") && strings.HasSuffix(inner, ")") {
+		cond := strings.TrimSuffix(strings.TrimPrefix(inner, "?("), ")")
+		// The operator is the first one: the value may contain another.
+		op := "=="
+		i := strings.Index(cond, "==")
+		if j := strings.Index(cond, "!="); j != -1 && (i == -1 || j < i) {
+			op, i = "!=", j
+		}
+		if i == -1 {
+			return segment{}, fmt.Errorf("unsupported filter %q", inner)
+		}
+		lhs := strings.TrimSpace(cond[:i])
+		rhs := strings.TrimSpace(cond[i+2:])
+		if !strings.HasPrefix(lhs, "@.") {
+			return segment{}, fmt.Errorf("unsupported filter %q: left side must start with '@.'", inner)
+		}
+		rhs = strings.Trim(rhs, `"'`)
+		return segment{
+			kind:       segmentFilter,
+			filterPath: strings.Split(strings.TrimPrefix(lhs, "@."), "."),
+			filterOp:   op,
+			filterVal:  rhs,
+		}, nil
+	}
+	if strings.HasPrefix(inner, "'") || strings.HasPrefix(inner, `"`) {
+		return segment{kind: segmentField, field: strings.Trim(inner, `"'`)}, nil
+	}
+	n, err := strconv.Atoi(inner)
+	if err != nil {
+		return segment{}, fmt.Errorf("unsupported index %q", inner)
+	}
+	return segment{kind: segmentIndex, index: n}, nil
+}
+
+// String returns the original expression.
+func (p *JSONPath) String() string {
+	return p.expr
+}
+
+// Find returns all values matched by the path in obj.
+func (p *JSONPath) Find(obj any) []any {
+	current := []any{obj}
+	for _, seg := range p.segments {
+		var next []any
+		for _, v := range current {
+			next = append(next, seg.apply(v)...)
+		}
+		current = next
+	}
+	return current
+}
+
+// Evaluate returns the matched values formatted the way kubectl prints them:
+// scalars are printed verbatim, objects and lists as JSON, separated by spaces.
+func (p *JSONPath) Evaluate(obj any) string {
+	var parts []string
+	for _, v := range p.Find(obj) {
+		parts = append(parts, formatValue(v))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (s segment) apply(v any) []any {
+	switch s.kind {
+	case segmentField:
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		child, ok := m[s.field]
+		if !ok {
+			return nil
+		}
+		return []any{child}
+	case segmentIndex:
+		l, ok := v.([]any)
+		if !ok {
+			return nil
+		}
+		i := s.index
+		if i < 0 {
+			i += len(l)
+		}
+		if i < 0 || i >= len(l) {
+			return nil
+		}
+		return []any{l[i]}
+	case segmentWildcard:
+		switch t := v.(type) {
+		case []any:
+			return t
+		case map[string]any:
+			// In key order, like kubectl, so that the output is stable.
+			keys := make([]string, 0, len(t))
+			for key := range t {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			var out []any
+			for _, key := range keys {
+				out = append(out, t[key])
+			}
+			return out
+		}
+		return nil
+	case segmentFilter:
+		l, ok := v.([]any)
+		if !ok {
+			return nil
+		}
+		var out []any
+		for _, item := range l {
+			var field any = item
+			for _, key := range s.filterPath {
+				m, ok := field.(map[string]any)
+				if !ok {
+					field = nil
+					break
+				}
+				field = m[key]
+			}
+			matched := field != nil && formatValue(field) == s.filterVal
+			if (s.filterOp == "==") == matched {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func formatValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	case nil:
+		return ""
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Sprintf("%v", t)
+		}
+		return string(b)
+	}
+}
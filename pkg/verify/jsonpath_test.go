@@ -0,0 +1,205 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCompileJSONPath(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    []segment
+		wantErr bool
+	}{
+		{
+			expr: "{.status.availableReplicas}",
+			want: []segment{{kind: segmentField, field: "status"}, {kind: segmentField, field: "availableReplicas"}},
+		},
+		{
+			expr: "$.spec.containers[0].image",
+			want: []segment{
+				{kind: segmentField, field: "spec"},
+				{kind: segmentField, field: "containers"},
+				{kind: segmentIndex, index: 0},
+				{kind: segmentField, field: "image"},
+			},
+		},
+		{
+			expr: "{.items[-1]}",
+			want: []segment{{kind: segmentField, field: "items"}, {kind: segmentIndex, index: -1}},
+		},
+		{
+			expr: "{.data[*]}",
+			want: []segment{{kind: segmentField, field: "data"}, {kind: segmentWildcard}},
+		},
+		{
+			expr: "{.metadata.labels['app.kubernetes.io/name']}",
+			want: []segment{
+				{kind: segmentField, field: "metadata"},
+				{kind: segmentField, field: "labels"},
+				{kind: segmentField, field: "app.kubernetes.io/name"},
+			},
+		},
+		{
+			expr: `{.status.conditions[?(@.type=="Ready")].status}`,
+			want: []segment{
+				{kind: segmentField, field: "status"},
+				{kind: segmentField, field: "conditions"},
+				{kind: segmentFilter, filterPath: []string{"type"}, filterOp: "==", filterVal: "Ready"},
+				{kind: segmentField, field: "status"},
+			},
+		},
+		{
+			expr: `{.items[?(@.metadata.name != 'web')]}`,
+			want: []segment{
+				{kind: segmentField, field: "items"},
+				{kind: segmentFilter, filterPath: []string{"metadata", "name"}, filterOp: "!=", filterVal: "web"},
+			},
+		},
+		{
+			// A ']' in a filter value does not close the bracket.
+			expr: `{.items[?(@.name=="a]b")].value}`,
+			want: []segment{
+				{kind: segmentField, field: "items"},
+				{kind: segmentFilter, filterPath: []string{"name"}, filterOp: "==", filterVal: "a]b"},
+				{kind: segmentField, field: "value"},
+			},
+		},
+		{
+			// The operator is the first one, even if the value contains another.
+			expr: `{.items[?(@.name!="a==b")]}`,
+			want: []segment{
+				{kind: segmentField, field: "items"},
+				{kind: segmentFilter, filterPath: []string{"name"}, filterOp: "!=", filterVal: "a==b"},
+			},
+		},
+		{expr: "{.status.}", wantErr: true},
+		{expr: "{.items[0}", wantErr: true},
+		{expr: `{.items[?(@.name=="a]")}`, wantErr: true},
+		{expr: "{.items[first]}", wantErr: true},
+		{expr: `{.items[?(@.name>"a")]}`, wantErr: true},
+		{expr: `{.items[?(name=="a")]}`, wantErr: true},
+		{expr: "status", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := CompileJSONPath(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("CompileJSONPath(%q) = %+v, want an error", tt.expr, p.segments)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompileJSONPath(%q) failed: %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(p.segments, tt.want) {
+				t.Errorf("CompileJSONPath(%q) = %+v, want %+v", tt.expr, p.segments, tt.want)
+			}
+		})
+	}
+}
+
+const testDeployment = `{
+  "kind": "Deployment",
+  "metadata": {"name": "web", "labels": {"app": "web", "tier": "frontend", "app.kubernetes.io/name": "web"}},
+  "spec": {
+    "replicas": 3,
+    "paused": false,
+    "template": {"spec": {"containers": [
+      {"name": "nginx", "image": "nginx:1.27", "ports": [{"containerPort": 80}]},
+      {"name": "sidecar", "image": "busybox"}
+    ]}}
+  },
+  "status": {
+    "availableReplicas": 3,
+    "conditions": [
+      {"type": "Available", "status": "True"},
+      {"type": "Progressing", "status": "False", "reason": "a]b"}
+    ]
+  }
+}`
+
+func TestJSONPathEvaluate(t *testing.T) {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(testDeployment), &obj); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"{.status.availableReplicas}", "3"},
+		{"{.spec.paused}", "false"},
+		{"{.metadata.name}", "web"},
+		{"{.metadata.missing}", ""},
+		{"{.spec.template.spec.containers[1].image}", "busybox"},
+		{"{.spec.template.spec.containers[-1].name}", "sidecar"},
+		{"{.spec.template.spec.containers[2].name}", ""},
+		{"{.spec.template.spec.containers[*].name}", "nginx sidecar"},
+		{"{.spec.template.spec.containers[0].ports}", `[{"containerPort":80}]`},
+		{"{.metadata.labels['app.kubernetes.io/name']}", "web"},
+		// Map wildcards are in key order.
+		{"{.metadata.labels[*]}", "web web frontend"},
+		{`{.status.conditions[?(@.type=="Available")].status}`, "True"},
+		{`{.status.conditions[?(@.type!="Available")].type}`, "Progressing"},
+		{`{.status.conditions[?(@.reason=="a]b")].type}`, "Progressing"},
+		{`{.status.conditions[?(@.type=="Ready")].status}`, ""},
+		// A filter over something that is not a list matches nothing.
+		{`{.metadata[?(@.name=="web")]}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			p, err := CompileJSONPath(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileJSONPath(%q) failed: %v", tt.expr, err)
+			}
+			// Repeat to catch unstable ordering.
+			for range 10 {
+				if got := p.Evaluate(obj); got != tt.want {
+					t.Fatalf("Evaluate(%q) = %q, want %q", tt.expr, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestConditionStatusPath(t *testing.T) {
+	obj := map[string]any{
+		"status": map[string]any{
+			"conditions": []any{
+				map[string]any{"type": `Odd")].x["`, "status": "True"},
+				map[string]any{"type": "Ready", "status": "False"},
+			},
+		},
+	}
+	tests := []struct {
+		condition string
+		want      string
+	}{
+		{"Ready", "False"},
+		{`Odd")].x["`, "True"},
+		{"Missing", ""},
+	}
+	for _, tt := range tests {
+		if got := conditionStatusPath(tt.condition).Evaluate(obj); got != tt.want {
+			t.Errorf("condition %q: got status %q, want %q", tt.condition, got, tt.want)
+		}
+	}
+}
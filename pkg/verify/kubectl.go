@@ -0,0 +1,91 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package verify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Client fetches objects from a cluster.
+type Client interface {
+	// Get returns the objects identified by resource ("kind" or "kind/name"),
+	// optionally filtered by namespace, label selector and field selector.
+	// A named object that does not exist yields no objects and no error.
+	Get(ctx context.Context, resource, namespace, selector, fieldSelector string) ([]map[string]any, error)
+}
+
+// Kubectl is a Client backed by the kubectl binary.
+type Kubectl struct {
+	KubeConfig string
+}
+
+func NewKubectl(kubeConfig string) Client {
+	return &Kubectl{KubeConfig: kubeConfig}
+}
+
+func (k *Kubectl) Get(ctx context.Context, resource, namespace, selector, fieldSelector string) ([]map[string]any, error) {
+	args := []string{"get", resource, "--ignore-not-found", "-o", "json"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	}
+	if selector != "" {
+		args = append(args, "--selector", selector)
+	}
+	if fieldSelector != "" {
+		args = append(args, "--field-selector", fieldSelector)
+	}
+
+	out, err := RunKubectl(ctx, k.KubeConfig, args...)
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return nil, nil
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(out, &obj); err != nil {
+		return nil, fmt.Errorf("parsing kubectl output: %w", err)
+	}
+	if items, ok := obj["items"].([]any); ok && strings.HasSuffix(fmt.Sprint(obj["kind"]), "List") {
+		var objects []map[string]any
+		for _, item := range items {
+			if m, ok := item.(map[string]any); ok {
+				objects = append(objects, m)
+			}
+		}
+		return objects, nil
+	}
+	return []map[string]any{obj}, nil
+}
+
+// RunKubectl runs kubectl against kubeConfig and returns its stdout; errors include its stderr.
+func RunKubectl(ctx context.Context, kubeConfig string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", kubeConfig))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("running kubectl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
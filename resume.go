@@ -0,0 +1,143 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+	"sigs.k8s.io/yaml"
+)
+
+// attemptsDir holds the results of earlier attempts at a unit, moved aside by --rerun.
+const attemptsDir = "attempts"
+
+// resultStates are the values of TaskResult.Result that --rerun can select.
+var resultStates = []string{"success", "fail", "error", model.ResultSkipped, model.ResultCancelled}
+
+// runUnit identifies one run: a task (prompt variant and iteration) for one LLM configuration.
+type runUnit struct {
+	Task        string
+	Variant     string
+	Iteration   int
+	LLMConfigID string
+}
+
+func (u runUnit) String() string {
+	s := u.Task
+	if u.Variant != "" {
+		s += " (" + u.Variant + ")"
+	}
+	if u.Iteration != 0 {
+		s += fmt.Sprintf(" #%d", u.Iteration)
+	}
+	return s + " for " + u.LLMConfigID
+}
+
+func unitOf(result model.TaskResult) runUnit {
+	return runUnit{Task: result.Task, Variant: result.Variant, Iteration: result.Iteration, LLMConfigID: result.LLMConfig.ID}
+}
+
+// newResult returns the result for a run of the task, identified by task, variant, iteration and LLM config.
+func (config *EvalConfig) newResult(taskID string, task *Task, llmConfig model.LLMConfig) model.TaskResult {
+	result := model.TaskResult{
+		Task:       taskID,
+		LLMConfig:  llmConfig,
+		Difficulty: task.Difficulty,
+		Category:   task.Category,
+		Tags:       task.Tags,
+	}
+	if len(task.Variants) > 0 {
+		result.Variant = task.variant
+	}
+	if config.Iterations > 1 {
+		result.Iteration = task.iteration
+	}
+	return result
+}
+
+// existingResult is a result already present in the output directory.
+type existingResult struct {
+	result model.TaskResult
+	dir    string
+}
+
+// loadExistingResults reads the complete results in outputDir, keyed by unit.
+// Earlier attempts are ignored, as are results files that cannot be parsed (e.g. half written).
+func loadExistingResults(outputDir string) (map[runUnit]existingResult, error) {
+	existing := make(map[runUnit]existingResult)
+	err := filepath.Walk(outputDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == outputDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if info.IsDir() && info.Name() == attemptsDir {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || info.Name() != "results.yaml" {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading file %s: %w", path, err)
+		}
+		var result model.TaskResult
+		if err := yaml.Unmarshal(data, &result); err != nil || result.Result == "" {
+			fmt.Printf("Warning: ignoring incomplete results file %s\n", path)
+			return nil
+		}
+		existing[unitOf(result)] = existingResult{result: result, dir: filepath.Dir(path)}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// archiveAttempt moves the files of a previous attempt in dir into dir/attempts/<n>,
+// returning the number of attempts archived so far.
+func archiveAttempt(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	n := 1
+	for {
+		if _, err := os.Stat(filepath.Join(dir, attemptsDir, strconv.Itoa(n))); os.IsNotExist(err) {
+			break
+		}
+		n++
+	}
+	archive := filepath.Join(dir, attemptsDir, strconv.Itoa(n))
+	if err := os.MkdirAll(archive, 0755); err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		// Subdirectories hold other units (variants, iterations) or earlier attempts.
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Rename(filepath.Join(dir, entry.Name()), filepath.Join(archive, entry.Name())); err != nil {
+			return 0, fmt.Errorf("archiving previous attempt: %w", err)
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,281 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/agent"
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster/pool"
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+	"sigs.k8s.io/yaml"
+)
+
+// RunConfig is the file given to run --config: the models to benchmark, expanded over a matrix
+// of agent features into LLM configs, and the settings of the run. Flags given on the command
+// line override the fields they correspond to.
+type RunConfig struct {
+	Providers []ProviderConfig `json:"providers,omitempty"`
+	Matrix    MatrixConfig     `json:"matrix,omitempty"`
+
+	// Quiet runs the agents non-interactively for tasks with a single prompt (see --quiet).
+	Quiet *bool `json:"quiet,omitempty"`
+
+	// AgentBin is the default kubectl-ai binary, for agents of the matrix that do not name one.
+	AgentBin string `json:"agentBin,omitempty"`
+
+	Tasks   RunTasksConfig   `json:"tasks,omitempty"`
+	Cluster RunClusterConfig `json:"cluster,omitempty"`
+	Output  RunOutputConfig  `json:"output,omitempty"`
+
+	Concurrency      *int   `json:"concurrency,omitempty"`
+	ModelConcurrency string `json:"modelConcurrency,omitempty"`
+	Iterations       int    `json:"iterations,omitempty"`
+	// Seed is the seed for sampling task variables; 0 picks a random one, as with --seed.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// ProviderConfig declares the models of an LLM provider. Its base URL, env and agent args apply to
+// all of them; the models' own are added to (or, for the base URL, replace) the provider's.
+type ProviderConfig struct {
+	ID        string            `json:"id"`
+	BaseURL   string            `json:"baseURL,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	AgentArgs []string          `json:"agentArgs,omitempty"`
+	Models    []ModelConfig     `json:"models"`
+}
+
+// ModelConfig declares a model, either as its ID alone or with its own settings.
+type ModelConfig struct {
+	ID        string            `json:"id"`
+	BaseURL   string            `json:"baseURL,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	AgentArgs []string          `json:"agentArgs,omitempty"`
+}
+
+func (m *ModelConfig) UnmarshalJSON(data []byte) error {
+	var id string
+	if err := json.Unmarshal(data, &id); err == nil {
+		*m = ModelConfig{ID: id}
+		return nil
+	}
+	// A distinct type, so that decoding does not recurse into this method.
+	type modelConfig ModelConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode((*modelConfig)(m))
+}
+
+// MatrixConfig lists the values of each dimension every model is run with; an empty dimension
+// takes its value from the flags.
+type MatrixConfig struct {
+	ToolUseShim []bool              `json:"toolUseShim,omitempty"`
+	MCPClient   []bool              `json:"mcpClient,omitempty"`
+	Agents      []model.AgentConfig `json:"agents,omitempty"`
+}
+
+// RunTasksConfig selects the tasks to run.
+type RunTasksConfig struct {
+	Dir            string   `json:"dir,omitempty"`
+	Pattern        string   `json:"pattern,omitempty"`
+	Difficulties   []string `json:"difficulties,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	ExcludeTags    []string `json:"excludeTags,omitempty"`
+	PromptVariants string   `json:"promptVariants,omitempty"`
+}
+
+// RunClusterConfig configures the clusters the tasks run against.
+type RunClusterConfig struct {
+	Provider              string                `json:"provider,omitempty"`
+	CreationPolicy        ClusterCreationPolicy `json:"creationPolicy,omitempty"`
+	KubeConfig            string                `json:"kubeconfig,omitempty"`
+	HostClusterContext    string                `json:"hostClusterContext,omitempty"`
+	HostClusterKubeConfig string                `json:"hostClusterKubeconfig,omitempty"`
+	PoolSize              *int                  `json:"poolSize,omitempty"`
+	PoolReset             pool.ResetStrategy    `json:"poolReset,omitempty"`
+}
+
+// RunOutputConfig configures where results are written.
+type RunOutputConfig struct {
+	Dir string `json:"dir,omitempty"`
+}
+
+// llmMatrix is what the LLM configs of a run are expanded from.
+type llmMatrix struct {
+	MatrixConfig
+	providers []ProviderConfig
+	quiet     bool
+}
+
+func loadRunConfig(path string) (*RunConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading run config: %w", err)
+	}
+	var runConfig RunConfig
+	if err := yaml.UnmarshalStrict(data, &runConfig); err != nil {
+		return nil, fmt.Errorf("parsing run config %q: %w", path, err)
+	}
+	return &runConfig, nil
+}
+
+// apply sets the fields of config and matrix declared in the run config, except those whose flag is set.
+func (rc *RunConfig) apply(config *EvalConfig, matrix *llmMatrix, set map[string]bool) error {
+	setString := func(flag string, dst *string, value string) {
+		if value != "" && !set[flag] {
+			*dst = value
+		}
+	}
+	setList := func(flag string, dst *[]string, value []string) {
+		if len(value) > 0 && !set[flag] {
+			*dst = value
+		}
+	}
+
+	if len(rc.Providers) > 0 && !set["models"] && !set["llm-provider"] {
+		matrix.providers = rc.Providers
+	}
+	if len(rc.Matrix.ToolUseShim) > 0 && !set["enable-tool-use-shim"] {
+		matrix.ToolUseShim = rc.Matrix.ToolUseShim
+	}
+	if len(rc.Matrix.MCPClient) > 0 && !set["mcp-client"] {
+		matrix.MCPClient = rc.Matrix.MCPClient
+	}
+	if len(rc.Matrix.Agents) > 0 && !set["agent-config"] {
+		matrix.Agents = rc.Matrix.Agents
+	}
+	if rc.Quiet != nil && !set["quiet"] {
+		matrix.quiet = *rc.Quiet
+	}
+	setString("agent-bin", &config.AgentBin, rc.AgentBin)
+
+	setString("tasks-dir", &config.TasksDir, rc.Tasks.Dir)
+	setString("task-pattern", &config.TaskPattern, rc.Tasks.Pattern)
+	setList("difficulty", &config.Difficulties, rc.Tasks.Difficulties)
+	setList("tags", &config.Tags, rc.Tasks.Tags)
+	setList("exclude-tags", &config.ExcludeTags, rc.Tasks.ExcludeTags)
+	setString("prompt-variants", &config.PromptVariants, rc.Tasks.PromptVariants)
+
+	setString("cluster-provider", &config.ClusterProvider, rc.Cluster.Provider)
+	setString("cluster-creation-policy", (*string)(&config.ClusterCreationPolicy), string(rc.Cluster.CreationPolicy))
+	setString("kubeconfig", &config.KubeConfig, rc.Cluster.KubeConfig)
+	setString("host-cluster-context", &config.HostClusterContext, rc.Cluster.HostClusterContext)
+	setString("host-cluster-kubeconfig", &config.HostClusterKubeConfig, rc.Cluster.HostClusterKubeConfig)
+	if rc.Cluster.PoolSize != nil && !set["cluster-pool-size"] {
+		config.ClusterPoolSize = *rc.Cluster.PoolSize
+	}
+	setString("cluster-pool-reset", (*string)(&config.ClusterPoolReset), string(rc.Cluster.PoolReset))
+
+	setString("output-dir", &config.OutputDir, rc.Output.Dir)
+
+	if rc.Concurrency != nil && !set["concurrency"] {
+		config.Concurrency = *rc.Concurrency
+	}
+	if rc.ModelConcurrency != "" && !set["model-concurrency"] {
+		perModelLimit, modelLimits, err := parseModelConcurrency(rc.ModelConcurrency)
+		if err != nil {
+			return err
+		}
+		config.MaxConcurrencyPerModel, config.ModelConcurrency = perModelLimit, modelLimits
+	}
+	if rc.Iterations != 0 && !set["iterations"] {
+		config.Iterations = rc.Iterations
+	}
+	if rc.Seed != nil && !set["seed"] {
+		config.Seed = *rc.Seed
+	}
+	return nil
+}
+
+// expand returns an LLM config for every agent, model, tool use shim and MCP client setting of the matrix.
+func (m *llmMatrix) expand(agentBin string) ([]model.LLMConfig, error) {
+	var llmConfigs []model.LLMConfig
+	ids := make(map[string]bool)
+	for _, agentConfig := range m.Agents {
+		if agentConfig.Type == "" {
+			agentConfig.Type = agent.TypeKubectlAI
+		}
+		if agentConfig.Type == agent.TypeKubectlAI && agentConfig.Bin == "" {
+			agentConfig.Bin = agentBin
+		}
+		for _, provider := range m.providers {
+			if provider.ID == "" {
+				return nil, fmt.Errorf("provider without an id")
+			}
+			for _, modelConfig := range provider.Models {
+				if modelConfig.ID == "" {
+					return nil, fmt.Errorf("model of provider %q without an id", provider.ID)
+				}
+				baseURL := provider.BaseURL
+				if modelConfig.BaseURL != "" {
+					baseURL = modelConfig.BaseURL
+				}
+				var env map[string]string
+				if len(provider.Env)+len(modelConfig.Env) > 0 {
+					env = maps.Clone(provider.Env)
+					if env == nil {
+						env = make(map[string]string)
+					}
+					maps.Copy(env, modelConfig.Env)
+				}
+				agentArgs := append(append([]string(nil), provider.AgentArgs...), modelConfig.AgentArgs...)
+
+				for _, toolUseShim := range m.ToolUseShim {
+					for _, mcpClient := range m.MCPClient {
+						llmConfig := model.LLMConfig{
+							ID:                llmConfigID(agentConfig, provider.ID, modelConfig.ID, toolUseShim, mcpClient),
+							ProviderID:        provider.ID,
+							ModelID:           modelConfig.ID,
+							EnableToolUseShim: toolUseShim,
+							Quiet:             m.quiet,
+							McpClient:         mcpClient,
+							BaseURL:           baseURL,
+							Env:               env,
+							AgentArgs:         agentArgs,
+							Agent:             agentConfig,
+						}
+						if ids[llmConfig.ID] {
+							return nil, fmt.Errorf("the matrix has several LLM configs with ID %q; give each agent a distinct name", llmConfig.ID)
+						}
+						ids[llmConfig.ID] = true
+						llmConfigs = append(llmConfigs, llmConfig)
+					}
+				}
+			}
+		}
+	}
+	return llmConfigs, nil
+}
+
+// llmConfigID identifies an LLM config in results, e.g. "shim_disabled-gemini-gemini-2.5-pro",
+// prefixed with the agent unless it is the default kubectl-ai and suffixed with "-mcp" if the MCP client is enabled.
+func llmConfigID(agentConfig model.AgentConfig, providerID, modelID string, toolUseShim, mcpClient bool) string {
+	toolUseShimStr := "shim_disabled"
+	if toolUseShim {
+		toolUseShimStr = "shim_enabled"
+	}
+	id := fmt.Sprintf("%s-%s-%s", toolUseShimStr, providerID, modelID)
+	if agentID := agent.ID(agentConfig); agentID != "" {
+		id = agentID + "-" + id
+	}
+	if mcpClient {
+		id += "-mcp"
+	}
+	return id
+}
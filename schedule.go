@@ -0,0 +1,184 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// workUnit is the unit of scheduling: one run of a task (prompt variant and iteration) for one LLM config.
+type workUnit struct {
+	taskID    string
+	task      Task
+	llmConfig model.LLMConfig
+}
+
+// runsExclusively reports whether runs of the task must not overlap. Only namespace isolation gives
+// each run its own resources; otherwise concurrent runs of the same task would share the objects it
+// creates in the cluster or, with cluster isolation, the cluster name and kubeconfig file.
+func (config *EvalConfig) runsExclusively(task *Task) bool {
+	return task.Isolation != IsolationModeNamespace || config.ClusterProvider == "vcluster"
+}
+
+// needsIsolatedClusters reports whether any of the tasks runs in a cluster of its own.
+func needsIsolatedClusters(config EvalConfig, tasks map[string]Task) bool {
+	if config.ClusterProvider == "vcluster" {
+		return true
+	}
+	for _, task := range tasks {
+		if task.Isolation == IsolationModeCluster {
+			return true
+		}
+	}
+	return false
+}
+
+// concurrencyLimit returns the maximum number of concurrent runs of the model, 0 meaning no limit.
+func (config *EvalConfig) concurrencyLimit(modelID string) int {
+	if n, ok := config.ModelConcurrency[modelID]; ok {
+		return n
+	}
+	return config.MaxConcurrencyPerModel
+}
+
+// parseModelConcurrency parses --model-concurrency: a default limit for every model and/or
+// per-model limits, e.g. "2" or "2,gemini-2.5-pro=1".
+func parseModelConcurrency(s string) (int, map[string]int, error) {
+	defaultLimit := 0
+	limits := make(map[string]int)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		modelID, value, perModel := strings.Cut(part, "=")
+		if !perModel {
+			value = modelID
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, nil, fmt.Errorf("invalid --model-concurrency %q, expected <n> or <model>=<n>", part)
+		}
+		if perModel {
+			limits[modelID] = n
+		} else {
+			defaultLimit = n
+		}
+	}
+	return defaultLimit, limits, nil
+}
+
+// interleave orders the units round-robin across models, keeping the order of each model's units,
+// so that every model makes progress instead of one slow model holding up the queue.
+func interleave(units []workUnit) []workUnit {
+	var models []string
+	byModel := make(map[string][]workUnit)
+	for _, unit := range units {
+		modelID := unit.llmConfig.ModelID
+		if _, ok := byModel[modelID]; !ok {
+			models = append(models, modelID)
+		}
+		byModel[modelID] = append(byModel[modelID], unit)
+	}
+	interleaved := make([]workUnit, 0, len(units))
+	for len(interleaved) < len(units) {
+		for _, modelID := range models {
+			if queue := byModel[modelID]; len(queue) > 0 {
+				interleaved = append(interleaved, queue[0])
+				byModel[modelID] = queue[1:]
+			}
+		}
+	}
+	return interleaved
+}
+
+// scheduler hands out work units to the workers, in order, skipping those that would exceed their
+// model's concurrency limit or overlap with a run of the same task that must run exclusively.
+type scheduler struct {
+	config *EvalConfig
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []workUnit
+	// running counts the runs in progress per model.
+	running map[string]int
+	// busy holds the tasks with a run in progress that must run exclusively.
+	busy map[string]bool
+}
+
+func newScheduler(ctx context.Context, config *EvalConfig, units []workUnit) *scheduler {
+	s := &scheduler{
+		config:  config,
+		pending: interleave(units),
+		running: make(map[string]int),
+		busy:    make(map[string]bool),
+	}
+	s.cond = sync.NewCond(&s.mu)
+	// Wake up the waiting workers when the run is interrupted.
+	context.AfterFunc(ctx, func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.cond.Broadcast()
+	})
+	return s
+}
+
+// next blocks until a unit can start and returns it, or returns false once there is nothing left
+// to run or ctx is done. Every unit returned must be released with done.
+func (s *scheduler) next(ctx context.Context) (workUnit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		if ctx.Err() != nil || len(s.pending) == 0 {
+			return workUnit{}, false
+		}
+		for i, unit := range s.pending {
+			if !s.canStart(unit) {
+				continue
+			}
+			s.pending = append(s.pending[:i], s.pending[i+1:]...)
+			s.running[unit.llmConfig.ModelID]++
+			if s.config.runsExclusively(&unit.task) {
+				s.busy[unit.taskID] = true
+			}
+			return unit, true
+		}
+		s.cond.Wait()
+	}
+}
+
+func (s *scheduler) canStart(unit workUnit) bool {
+	if limit := s.config.concurrencyLimit(unit.llmConfig.ModelID); limit > 0 && s.running[unit.llmConfig.ModelID] >= limit {
+		return false
+	}
+	return !s.busy[unit.taskID]
+}
+
+// done releases a unit returned by next.
+func (s *scheduler) done(unit workUnit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running[unit.llmConfig.ModelID]--
+	if s.config.runsExclusively(&unit.task) {
+		delete(s.busy, unit.taskID)
+	}
+	s.cond.Broadcast()
+}
@@ -0,0 +1,132 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+func TestSchedulerSerializesRunsOfATask(t *testing.T) {
+	llmConfig := model.LLMConfig{ID: "m", ModelID: "m"}
+	var units []workUnit
+	for iteration := 1; iteration <= 2; iteration++ {
+		for _, isolation := range []IsolationMode{"", IsolationModeCluster, IsolationModeNamespace} {
+			task := Task{Isolation: isolation, iteration: iteration}
+			units = append(units, workUnit{taskID: "task-" + string(isolation), task: task, llmConfig: llmConfig})
+		}
+	}
+
+	ctx := context.Background()
+	s := newScheduler(ctx, &EvalConfig{}, units)
+	started := make(map[string]int)
+	for range 4 {
+		unit, ok := s.next(ctx)
+		if !ok {
+			t.Fatal("next returned no unit, want one")
+		}
+		started[unit.taskID]++
+	}
+	// The second iteration of a namespace-isolated task overlaps the first; the others wait.
+	want := map[string]int{"task-": 1, "task-cluster": 1, "task-namespace": 2}
+	for taskID, n := range want {
+		if started[taskID] != n {
+			t.Errorf("started %d runs of %s, want %d", started[taskID], taskID, n)
+		}
+	}
+
+	s.done(workUnit{taskID: "task-cluster", task: Task{Isolation: IsolationModeCluster}, llmConfig: llmConfig})
+	unit, ok := s.next(ctx)
+	if !ok || unit.taskID != "task-cluster" || unit.task.iteration != 2 {
+		t.Errorf("next = %s iteration %d, want the second iteration of task-cluster", unit.taskID, unit.task.iteration)
+	}
+}
+
+func TestParseModelConcurrency(t *testing.T) {
+	tests := []struct {
+		s            string
+		defaultLimit int
+		limits       map[string]int
+		wantErr      bool
+	}{
+		{s: "", limits: map[string]int{}},
+		{s: "2", defaultLimit: 2, limits: map[string]int{}},
+		{s: "2,m=1", defaultLimit: 2, limits: map[string]int{"m": 1}},
+		{s: " m=1 , gemini-2.5-pro=0 ", limits: map[string]int{"m": 1, "gemini-2.5-pro": 0}},
+		{s: "m=1,3", defaultLimit: 3, limits: map[string]int{"m": 1}},
+		{s: "two", wantErr: true},
+		{s: "-1", wantErr: true},
+		{s: "m=", wantErr: true},
+		{s: "2,m=x", wantErr: true},
+	}
+	for _, tt := range tests {
+		defaultLimit, limits, err := parseModelConcurrency(tt.s)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseModelConcurrency(%q) = %d, %v, want an error", tt.s, defaultLimit, limits)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseModelConcurrency(%q) failed: %v", tt.s, err)
+			continue
+		}
+		if defaultLimit != tt.defaultLimit || !reflect.DeepEqual(limits, tt.limits) {
+			t.Errorf("parseModelConcurrency(%q) = %d, %v, want %d, %v", tt.s, defaultLimit, limits, tt.defaultLimit, tt.limits)
+		}
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	unit := func(taskID, modelID string) workUnit {
+		return workUnit{taskID: taskID, llmConfig: model.LLMConfig{ID: modelID, ModelID: modelID}}
+	}
+	tests := []struct {
+		name  string
+		units []workUnit
+		want  []string
+	}{
+		{name: "empty"},
+		{
+			name:  "one model keeps its order",
+			units: []workUnit{unit("b", "m1"), unit("a", "m1")},
+			want:  []string{"b/m1", "a/m1"},
+		},
+		{
+			name:  "round-robin in order of first appearance",
+			units: []workUnit{unit("a", "m2"), unit("b", "m2"), unit("a", "m1"), unit("b", "m1"), unit("a", "m3")},
+			want:  []string{"a/m2", "a/m1", "a/m3", "b/m2", "b/m1"},
+		},
+		{
+			name:  "models with more units go on alone",
+			units: []workUnit{unit("a", "m1"), unit("b", "m1"), unit("c", "m1"), unit("a", "m2")},
+			want:  []string{"a/m1", "a/m2", "b/m1", "c/m1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, u := range interleave(tt.units) {
+				got = append(got, u.taskID+"/"+u.llmConfig.ModelID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("interleave = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/cluster"
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
+)
+
+// selfTestLLMConfig stands in for a model in selftest results; no agent is run.
+var selfTestLLMConfig = model.LLMConfig{ID: "selftest", ProviderID: "selftest", ModelID: "solution"}
+
+func runSelfTest(ctx context.Context) error {
+	start := time.Now()
+	config := EvalConfig{
+		TasksDir: "./tasks",
+	}
+
+	// Set custom usage for 'selftest' subcommand
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s selftest [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Check that tasks are solvable and their verifiers are correct by running their reference solutions.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	addClusterFlags(&config)
+	flag.StringVar(&config.TasksDir, "tasks-dir", config.TasksDir, "Directory containing evaluation tasks")
+	flag.StringVar(&config.TaskPattern, "task-pattern", config.TaskPattern, "Pattern to filter tasks (e.g. 'pod' or 'redis')")
+	flag.StringVar(&config.OutputDir, "output-dir", config.OutputDir, "Directory to write results to")
+	flag.Int64Var(&config.Seed, "seed", 0, "Seed for sampling task variables (0 = random)")
+	flag.Parse()
+
+	if config.Seed == 0 {
+		config.Seed = time.Now().UnixNano()
+	}
+	fmt.Printf("Using seed %d\n", config.Seed)
+
+	if err := resolveClusterConfig(&config); err != nil {
+		return err
+	}
+	config.LLMConfigs = []model.LLMConfig{selfTestLLMConfig}
+
+	tasks, err := loadTasks(config)
+	if err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+
+	// If concurrency is set to auto (0), use the number of tasks
+	if config.Concurrency == 0 {
+		config.Concurrency = len(tasks)
+		fmt.Printf("Auto-configuring concurrency to %d (number of tasks)\n", config.Concurrency)
+	}
+
+	results, err := runEvaluation(ctx, config, selfTestTask)
+	if err != nil {
+		return fmt.Errorf("running selftest: %w", err)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Task < results[j].Task
+	})
+	broken := 0
+	fmt.Println("\nSelftest Summary:")
+	fmt.Println("=================")
+	for _, result := range results {
+		switch result.Result {
+		case "success":
+			fmt.Printf("ok       %s\n", result.Task)
+		case model.ResultSkipped:
+			fmt.Printf("skipped  %s: %s\n", result.Task, result.SkipReason)
+		case model.ResultCancelled:
+			fmt.Printf("cancelled %s\n", result.Task)
+		default:
+			broken++
+			fmt.Printf("BROKEN   %s\n", result.Task)
+			if result.Error != "" {
+				fmt.Printf("         %s\n", result.Error)
+			}
+			for _, failure := range result.Failures {
+				fmt.Printf("         %s\n", failure.Message)
+			}
+		}
+	}
+
+	fmt.Printf("Total selftest time: %s\n", time.Since(start))
+	if broken > 0 {
+		return fmt.Errorf("%d of %d tasks are broken", broken, len(results))
+	}
+	return nil
+}
+
+// selfTestTask proves a task is solvable and its verifier is correct: after setup every weighted
+// verifier and assertion must fail (negative control), and after the reference solution is applied
+// they must all pass.
+func selfTestTask(ctx context.Context, config EvalConfig, taskID string, task Task, llmConfig model.LLMConfig, clusterProvider cluster.Provider, log io.Writer) (result model.TaskResult) {
+	result = config.newResult(taskID, &task, llmConfig)
+
+	if task.Solution == "" {
+		result.Skip("task has no solution script")
+		return result
+	}
+	if task.Verifier == "" && len(task.Verify) == 0 {
+		result.Skip("task has no verifier or verify assertions to check the solution against")
+		return result
+	}
+
+	timeout, err := task.TimeLimit()
+	if err != nil {
+		result.Result = "error"
+		result.Error = err.Error()
+		return result
+	}
+	taskCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	x, err := newTaskExecution(config, taskID, task, llmConfig, clusterProvider, log, &result)
+	if err != nil {
+		result.Result = "error"
+		result.Error = err.Error()
+		return result
+	}
+
+	defer x.cleanupWithTimeout(ctx)
+	defer recordCancellation(ctx, &result)
+
+	if err := x.runSetup(taskCtx); err != nil {
+		if errors.Is(err, errTaskSkipped) {
+			return result
+		}
+		result.Result = "error"
+		result.Error = err.Error()
+		return result
+	}
+
+	fmt.Printf("\nChecking that the verifier fails before the solution for task %s\n", taskID)
+	for _, check := range x.runVerifiers(taskCtx) {
+		if check.Passed && check.Weight > 0 {
+			result.AddFailure("%s passed before the solution was applied (negative control)", check.Name)
+		}
+	}
+
+	fmt.Printf("\nRunning solution for task %s\n", taskID)
+	cmd := exec.CommandContext(taskCtx, filepath.Join(x.taskDir, x.task.Solution))
+	cmd.Dir = x.taskDir
+	cmd.Env = x.scriptEnv()
+	if err := x.runCommand(cmd); err != nil {
+		result.AddFailure("solution script failed: %v", err)
+		return result
+	}
+
+	fmt.Printf("\nChecking that the verifier passes after the solution for task %s\n", taskID)
+	var failures []string
+	for _, check := range x.runVerifiers(taskCtx) {
+		if !check.Passed && check.Weight > 0 {
+			failures = append(failures, fmt.Sprintf("%s: %s", check.Name, check.Message))
+		}
+	}
+	if len(failures) > 0 {
+		result.AddFailure("verifier failed after the solution was applied: %s", strings.Join(failures, "; "))
+	}
+
+	if len(result.Failures) == 0 {
+		result.Result = "success"
+	}
+	return result
+}
+
+// runVerifiers runs the task verifier script and verify assertions, returning a check for each,
+// weighted as in a run: checks with weight 0 are informational and decide neither control.
+func (x *TaskExecution) runVerifiers(ctx context.Context) []model.Check {
+	var checks []model.Check
+	if x.task.Verifier != "" {
+		cmd := exec.CommandContext(ctx, filepath.Join(x.taskDir, x.task.Verifier))
+		cmd.Dir = x.taskDir
+		cmd.Env = x.scriptEnv()
+		check := model.Check{Name: x.task.Verifier, Kind: "verifier", Weight: model.CheckWeight(x.task.VerifierWeight)}
+		if err := x.runCommand(cmd); err != nil {
+			check.Message = err.Error()
+		} else {
+			check.Pass()
+		}
+		checks = append(checks, check)
+	}
+	if len(x.task.Verify) > 0 {
+		for i, assertion := range verify.Evaluate(ctx, verify.NewKubectl(x.kubeConfig), x.task.Verify) {
+			check := model.Check{Name: assertion.Assertion, Kind: "assertion", Weight: model.CheckWeight(x.task.Verify[i].Weight), Message: assertion.Message}
+			if assertion.Passed {
+				check.Pass()
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
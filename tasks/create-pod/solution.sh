@@ -0,0 +1,3 @@
+#!/usr/bin/env bash
+kubectl create namespace web-server
+kubectl run web-server --image=nginx -n web-server
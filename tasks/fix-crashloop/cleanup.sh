@@ -1,2 +1,3 @@
 #!/usr/bin/env bash
-kubectl delete namespace crashloop-test 
\ No newline at end of file
+NAMESPACE="${NAMESPACE:-crashloop-test}"
+kubectl delete namespace "${NAMESPACE}" 
\ No newline at end of file
@@ -1,13 +1,15 @@
 #!/usr/bin/env bash
-kubectl delete namespace crashloop-test --ignore-not-found
+NAMESPACE="${NAMESPACE:-crashloop-test}"
+DEPLOYMENT="${DEPLOYMENT:-app}"
+kubectl delete namespace "${NAMESPACE}" --ignore-not-found
 # Create namespace and a deployment with an invalid command that will cause crashloop
-kubectl create namespace crashloop-test
+kubectl create namespace "${NAMESPACE}"
 cat <<EOF | kubectl apply -f -
 apiVersion: apps/v1
 kind: Deployment
 metadata:
-  name: app
-  namespace: crashloop-test
+  name: ${DEPLOYMENT}
+  namespace: ${NAMESPACE}
 spec:
   replicas: 1
   selector:
@@ -24,11 +26,3 @@ spec:
         command: ["/bin/sh", "-c"]
         args: ["python3 -c 'print('Starting'))'"] 
 EOF
-
-# Wait for pod to enter crashloop state
-for i in {1..30}; do
-    if kubectl get pods -n crashloop-test -l app=nginx -o jsonpath='{.items[0].status.containerStatuses[0].restartCount}' | grep -q "[1-9]"; then
-        exit 0
-    fi
-    sleep 1
-done 
\ No newline at end of file
@@ -0,0 +1,6 @@
+#!/usr/bin/env bash
+NAMESPACE="${NAMESPACE:-crashloop-test}"
+DEPLOYMENT="${DEPLOYMENT:-app}"
+# Drop the broken command so the container runs the image default (nginx)
+kubectl patch deployment "${DEPLOYMENT}" -n "${NAMESPACE}" --type=json \
+  -p='[{"op": "remove", "path": "/spec/template/spec/containers/0/command"}, {"op": "remove", "path": "/spec/template/spec/containers/0/args"}]'
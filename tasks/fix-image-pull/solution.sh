@@ -0,0 +1,2 @@
+#!/usr/bin/env bash
+kubectl set image deployment/app nginx=nginx -n debug
@@ -0,0 +1,13 @@
+#!/usr/bin/env bash
+# Create an over-provisioned deployment in the task namespace
+kubectl create deployment web-app --image=nginx --replicas=4 -n "${TASK_NAMESPACE}"
+# Wait for initial deployment to be ready
+for i in {1..30}; do
+    if kubectl get deployment web-app -n "${TASK_NAMESPACE}" -o jsonpath='{.status.availableReplicas}' | grep -q "4"; then
+        exit 0
+    fi
+    sleep 2
+done
+
+echo "Setup failed for scale-deployment-clarify"
+exit 1
@@ -1,2 +0,0 @@
-#!/usr/bin/env bash
-kubectl delete namespace scale-test 
\ No newline at end of file
@@ -1,15 +1,13 @@
 #!/usr/bin/env bash
-# Create namespace and a deployment with initial replicas
-kubectl delete namespace scale-test --ignore-not-found
-kubectl create namespace scale-test
-kubectl create deployment web-app --image=nginx --replicas=1 -n scale-test
+# Create a deployment with initial replicas in the task namespace
+kubectl create deployment web-app --image=nginx --replicas=1 -n "${TASK_NAMESPACE}"
 # Wait for initial deployment to be ready
 for i in {1..30}; do
-    if kubectl get deployment web-app -n scale-test -o jsonpath='{.status.availableReplicas}' | grep -q "1"; then
+    if kubectl get deployment web-app -n "${TASK_NAMESPACE}" -o jsonpath='{.status.availableReplicas}' | grep -q "1"; then
         exit 0
     fi
     sleep 2
 done
 
 echo "Setup failed for scale-deployment"
-exit 1
\ No newline at end of file
+exit 1
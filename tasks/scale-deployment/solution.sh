@@ -0,0 +1,2 @@
+#!/usr/bin/env bash
+kubectl scale deployment web-app -n "${TASK_NAMESPACE}" --replicas=2
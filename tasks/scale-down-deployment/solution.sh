@@ -0,0 +1,2 @@
+#!/usr/bin/env bash
+kubectl scale deployment web-service -n scale-down-test --replicas=1
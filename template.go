@@ -0,0 +1,317 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/usersim"
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
+)
+
+// TaskVariable declares how a task variable is sampled.
+// Exactly one of Values, Prefix or Min/Max must be set.
+type TaskVariable struct {
+	// Values picks one of the listed values.
+	Values []string `json:"values,omitempty"`
+
+	// Prefix generates a DNS-compatible name of the form "<prefix>-<random suffix>".
+	Prefix string `json:"prefix,omitempty"`
+
+	// Min and Max pick an integer in the inclusive range [Min, Max].
+	Min *int `json:"min,omitempty"`
+	Max *int `json:"max,omitempty"`
+}
+
+// variableNameRegex restricts variable names so they are usable both as environment variables and in templates.
+var variableNameRegex = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+
+// Validate checks that the variable declares exactly one way of sampling a value.
+func (v *TaskVariable) Validate() error {
+	kinds := 0
+	if len(v.Values) > 0 {
+		kinds++
+	}
+	if v.Prefix != "" {
+		kinds++
+	}
+	if v.Min != nil || v.Max != nil {
+		kinds++
+		if v.Min == nil || v.Max == nil {
+			return fmt.Errorf("min and max must both be set")
+		}
+		if *v.Min > *v.Max {
+			return fmt.Errorf("min %d is greater than max %d", *v.Min, *v.Max)
+		}
+	}
+	if kinds != 1 {
+		return fmt.Errorf("exactly one of values, prefix or min/max must be set")
+	}
+	return nil
+}
+
+const suffixAlphabet = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+func (v *TaskVariable) sample(r *rand.Rand) string {
+	switch {
+	case len(v.Values) > 0:
+		return v.Values[r.Intn(len(v.Values))]
+	case v.Prefix != "":
+		suffix := make([]byte, 5)
+		for i := range suffix {
+			suffix[i] = suffixAlphabet[r.Intn(len(suffixAlphabet))]
+		}
+		return v.Prefix + "-" + string(suffix)
+	default:
+		return strconv.Itoa(*v.Min + r.Intn(*v.Max-*v.Min+1))
+	}
+}
+
+// instanceSeed derives the seed used to instantiate a task from the run seed,
+// so that every task gets different values but runs remain reproducible.
+func instanceSeed(seed int64, taskID string) int64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d/%s", seed, taskID)
+	return int64(h.Sum64())
+}
+
+// sampleVariables picks a concrete value for every task variable.
+// Builtins are variables provided by the harness (e.g. TASK_NAMESPACE); tasks may not redeclare them.
+func (t *Task) sampleVariables(seed int64, builtins map[string]string) (map[string]string, error) {
+	names := make([]string, 0, len(t.Variables))
+	for name := range t.Variables {
+		names = append(names, name)
+	}
+	// Sample in a stable order so the same seed always yields the same values.
+	sort.Strings(names)
+
+	r := rand.New(rand.NewSource(seed))
+	vars := make(map[string]string, len(names)+len(builtins))
+	for name, value := range builtins {
+		vars[name] = value
+	}
+	for _, name := range names {
+		if !variableNameRegex.MatchString(name) {
+			return nil, fmt.Errorf("variable name %q must match %s", name, variableNameRegex)
+		}
+		if _, ok := builtins[name]; ok {
+			return nil, fmt.Errorf("variable %q is provided by the harness and cannot be redeclared", name)
+		}
+		v := t.Variables[name]
+		if err := v.Validate(); err != nil {
+			return nil, fmt.Errorf("variable %q: %w", name, err)
+		}
+		vars[name] = v.sample(r)
+	}
+	return vars, nil
+}
+
+// Instantiate samples the task variables with the given seed and returns a copy of the task
+// whose prompts, expectations, ready gates and assertions are rendered with those values.
+// Builtins are added to the sampled variables as-is.
+// Prompt files are resolved relative to taskDir and inlined into the returned task.
+// Templates use Go text/template syntax, e.g. "the deployment {{.DEPLOYMENT}}".
+func (t *Task) Instantiate(taskDir string, seed int64, builtins map[string]string) (*Task, map[string]string, error) {
+	vars, err := t.sampleVariables(seed, builtins)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	render := func(field, s string) (string, error) {
+		out, err := renderTemplate(s, vars)
+		if err != nil {
+			return "", fmt.Errorf("rendering %s: %w", field, err)
+		}
+		return out, nil
+	}
+
+	instance := *t
+
+	instance.Script = make([]ScriptStep, len(t.Script))
+	for i, step := range t.Script {
+		prompt, err := step.ResolvePrompt(taskDir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("script[%d]: %w", i, err)
+		}
+		step.Prompt, err = render(fmt.Sprintf("script[%d].prompt", i), prompt)
+		if err != nil {
+			return nil, nil, err
+		}
+		step.PromptFile = ""
+		step.Expect, err = renderExpectations(step.Expect, vars)
+		if err != nil {
+			return nil, nil, fmt.Errorf("script[%d]: %w", i, err)
+		}
+		instance.Script[i] = step
+	}
+
+	instance.Expect, err = renderExpectations(t.Expect, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	instance.Ready, err = renderAssertions("ready", t.Ready, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+	instance.Verify, err = renderAssertions("verify", t.Verify, vars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if t.User != nil {
+		instance.User, err = renderUser(t.User, vars)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return &instance, vars, nil
+}
+
+func renderUser(user *usersim.Spec, vars map[string]string) (*usersim.Spec, error) {
+	out := *user
+	out.Facts = make([]string, len(user.Facts))
+	for i, fact := range user.Facts {
+		var err error
+		if out.Facts[i], err = renderTemplate(fact, vars); err != nil {
+			return nil, fmt.Errorf("rendering user.facts[%d]: %w", i, err)
+		}
+	}
+	out.Responses = make([]usersim.Response, len(user.Responses))
+	for i, r := range user.Responses {
+		var err error
+		if r.Match, err = renderRegex(r.Match, vars); err != nil {
+			return nil, fmt.Errorf("rendering user.responses[%d].match: %w", i, err)
+		}
+		if r.Reply, err = renderTemplate(r.Reply, vars); err != nil {
+			return nil, fmt.Errorf("rendering user.responses[%d].reply: %w", i, err)
+		}
+		out.Responses[i] = r
+	}
+	var err error
+	if out.Fallback, err = renderTemplate(user.Fallback, vars); err != nil {
+		return nil, fmt.Errorf("rendering user.fallback: %w", err)
+	}
+	return &out, nil
+}
+
+func renderAssertions(field string, assertions []verify.Assertion, vars map[string]string) ([]verify.Assertion, error) {
+	if assertions == nil {
+		return nil, nil
+	}
+	out := make([]verify.Assertion, len(assertions))
+	for i, a := range assertions {
+		for _, s := range []*string{&a.Name, &a.Resource, &a.Namespace, &a.Selector, &a.FieldSelector, &a.JSONPath, &a.Equals} {
+			var err error
+			if *s, err = renderTemplate(*s, vars); err != nil {
+				return nil, fmt.Errorf("rendering %s[%d]: %w", field, i, err)
+			}
+		}
+		var err error
+		if a.Matches, err = renderRegex(a.Matches, vars); err != nil {
+			return nil, fmt.Errorf("rendering %s[%d].matches: %w", field, i, err)
+		}
+		out[i] = a
+	}
+	return out, nil
+}
+
+func renderExpectations(expect []Expectation, vars map[string]string) ([]Expectation, error) {
+	if expect == nil {
+		return nil, nil
+	}
+	out := make([]Expectation, len(expect))
+	for i, e := range expect {
+		var err error
+		if e.Contains, err = renderRegex(e.Contains, vars); err != nil {
+			return nil, fmt.Errorf("rendering expect[%d].contains: %w", i, err)
+		}
+		if e.NotContains, err = renderRegex(e.NotContains, vars); err != nil {
+			return nil, fmt.Errorf("rendering expect[%d].notContains: %w", i, err)
+		}
+		if e.Set != nil {
+			set := SetExpectation{Expected: make([]string, len(e.Set.Expected))}
+			if set.Pattern, err = renderRegex(e.Set.Pattern, vars); err != nil {
+				return nil, fmt.Errorf("rendering expect[%d].set.pattern: %w", i, err)
+			}
+			for j, answer := range e.Set.Expected {
+				if set.Expected[j], err = renderTemplate(answer, vars); err != nil {
+					return nil, fmt.Errorf("rendering expect[%d].set.expected[%d]: %w", i, j, err)
+				}
+			}
+			e.Set = &set
+		}
+		if e.Judge != nil {
+			judge := *e.Judge
+			if judge.Rubric, err = renderTemplate(e.Judge.Rubric, vars); err != nil {
+				return nil, fmt.Errorf("rendering expect[%d].judge.rubric: %w", i, err)
+			}
+			if judge.Reference, err = renderTemplate(e.Judge.Reference, vars); err != nil {
+				return nil, fmt.Errorf("rendering expect[%d].judge.reference: %w", i, err)
+			}
+			e.Judge = &judge
+		}
+		out[i] = e
+	}
+	return out, nil
+}
+
+// renderTemplate renders s as a Go template over vars; referencing an undeclared variable is an error.
+func renderTemplate(s string, vars map[string]string) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tmpl, err := template.New("task").Option("missingkey=error").Parse(s)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, vars); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// renderRegex renders the regex s as a Go template over vars, with the values escaped so that they
+// match literally: a sampled image tag or host name must not change what the pattern matches.
+func renderRegex(s string, vars map[string]string) (string, error) {
+	quoted := make(map[string]string, len(vars))
+	for name, value := range vars {
+		quoted[name] = regexp.QuoteMeta(value)
+	}
+	return renderTemplate(s, quoted)
+}
+
+// variableEnv returns the sampled variables as environment variable assignments for task scripts.
+func variableEnv(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, fmt.Sprintf("%s=%s", name, vars[name]))
+	}
+	return env
+}
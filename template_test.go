@@ -0,0 +1,66 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/verify"
+)
+
+func TestRenderQuotesRegexFields(t *testing.T) {
+	vars := map[string]string{"IMAGE": "nginx:1.27.0", "HOST": "web.example.com"}
+	expect, err := renderExpectations([]Expectation{
+		{Contains: "image {{.IMAGE}}"},
+		{NotContains: "^{{.HOST}}$"},
+		{Set: &SetExpectation{Pattern: "{{.HOST}}: (\\S+)", Expected: []string{"{{.IMAGE}}"}}},
+	}, vars)
+	if err != nil {
+		t.Fatalf("renderExpectations failed: %v", err)
+	}
+	assertions, err := renderAssertions("verify", []verify.Assertion{
+		{Resource: "pods", JSONPath: "{.spec.containers[0].image}", Equals: "{{.IMAGE}}", Matches: "^{{.IMAGE}}"},
+	}, vars)
+	if err != nil {
+		t.Fatalf("renderAssertions failed: %v", err)
+	}
+
+	tests := []struct {
+		field, pattern, match, noMatch string
+	}{
+		{"contains", expect[0].Contains, "image nginx:1.27.0", "image nginx:1x27x0"},
+		{"notContains", expect[1].NotContains, "web.example.com", "webxexample.com"},
+		{"set.pattern", expect[2].Set.Pattern, "web.example.com: a", "web-example.com: a"},
+		{"matches", assertions[0].Matches, "nginx:1.27.0", "nginx:1227.0"},
+	}
+	for _, tt := range tests {
+		re, err := regexp.Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("%s: %q does not compile: %v", tt.field, tt.pattern, err)
+			continue
+		}
+		if !re.MatchString(tt.match) || re.MatchString(tt.noMatch) {
+			t.Errorf("%s: %q should match %q but not %q", tt.field, tt.pattern, tt.match, tt.noMatch)
+		}
+	}
+	// Literal fields keep the values as they are.
+	if got := expect[2].Set.Expected[0]; got != "nginx:1.27.0" {
+		t.Errorf("set.expected = %q, want nginx:1.27.0", got)
+	}
+	if got := assertions[0].Equals; got != "nginx:1.27.0" {
+		t.Errorf("equals = %q, want nginx:1.27.0", got)
+	}
+}
@@ -224,16 +224,23 @@ func generateTask(cfg Config, task TaskMetadata) error {
 		return err
 	}
 
-	contains, notContains := buildExpectations(artifacts)
+	violating, compliant := buildExpectations(artifacts)
 	var expectLines []string
-	for _, name := range contains {
-		expectLines = append(expectLines, fmt.Sprintf(`- contains: "VIOLATING: %s"`, regexp.QuoteMeta(name)))
-	}
-	for _, name := range notContains {
-		expectLines = append(expectLines, fmt.Sprintf(`- notContains: "VIOLATING: %s"`, regexp.QuoteMeta(name)))
-	}
-	if len(expectLines) == 0 {
-		expectLines = append(expectLines, `- contains: "VIOLATING: resource-beta-\\d+"`)
+	if len(violating) > 0 {
+		// Score the reported violations as a set, so partial answers get precision/recall credit.
+		// Compliant (alpha) resources are implicitly false positives if reported.
+		expectLines = append(expectLines, "- set:", `    pattern: 'VIOLATING: (\S+)'`, "    expected:")
+		for _, name := range violating {
+			expectLines = append(expectLines, fmt.Sprintf("    - %q", name))
+		}
+	} else {
+		// Nothing violates the constraint, so the correct answer reports no violations at all.
+		for _, name := range compliant {
+			expectLines = append(expectLines, fmt.Sprintf(`- notContains: "VIOLATING: %s"`, regexp.QuoteMeta(name)))
+		}
+		if len(expectLines) == 0 {
+			expectLines = append(expectLines, "- set:", `    pattern: 'VIOLATING: (\S+)'`, "    expected: []")
+		}
 	}
 
 	// Write task.yaml
@@ -246,6 +253,10 @@ expect:
 %s
 isolation: cluster
 timeout: 5m
+difficulty: medium
+category: policy
+tags:
+- gatekeeper
 `, indent(prompt, "    "), strings.Join(expectLines, "\n"))
 	os.WriteFile(filepath.Join(outDir, "task.yaml"), []byte(taskYAML), 0644)
 
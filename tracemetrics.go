@@ -0,0 +1,95 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gke-labs/k8s-ai-bench/pkg/model"
+)
+
+// maxReportedVerbs is the number of kubectl verbs listed per LLM config, most used first.
+const maxReportedVerbs = 5
+
+// printAgentMetrics writes the trace metrics of each LLM config, averaged over its runs that have
+// a trace, with the kubectl verbs used in the most runs.
+func printAgentMetrics(buffer *strings.Builder, results []model.TaskResult) {
+	type summary struct {
+		runs                                  int
+		llmCalls, toolCalls, mutating, errors int
+		modelSeconds, toolSeconds             float64
+		verbRuns                              map[string]int
+	}
+	summaries := make(map[string]*summary)
+	var ids []string
+	for _, result := range results {
+		m := result.Metrics
+		if m == nil {
+			continue
+		}
+		id := result.LLMConfig.ID
+		s := summaries[id]
+		if s == nil {
+			s = &summary{verbRuns: make(map[string]int)}
+			summaries[id] = s
+			ids = append(ids, id)
+		}
+		s.runs++
+		s.llmCalls += m.LLMCalls
+		s.toolCalls += m.ToolCalls
+		s.mutating += m.MutatingCommands
+		s.errors += m.ToolErrors
+		s.modelSeconds += m.ModelSeconds
+		s.toolSeconds += m.ToolSeconds
+		for _, verb := range m.KubectlVerbs {
+			s.verbRuns[verb]++
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	sort.Strings(ids)
+
+	buffer.WriteString("## Agent Behavior\n\n")
+	buffer.WriteString("Means per run, from the agents' traces.\n\n")
+	buffer.WriteString("| LLM Config | Runs | LLM Calls | Tool Calls | Mutating Commands | Tool Errors | Model Time (s) | Tool Time (s) | Top kubectl Verbs |\n")
+	buffer.WriteString("|------------|------|-----------|------------|-------------------|-------------|----------------|---------------|-------------------|\n")
+	for _, id := range ids {
+		s := summaries[id]
+		n := float64(s.runs)
+
+		verbs := make([]string, 0, len(s.verbRuns))
+		for verb := range s.verbRuns {
+			verbs = append(verbs, verb)
+		}
+		sort.Slice(verbs, func(i, j int) bool {
+			if s.verbRuns[verbs[i]] != s.verbRuns[verbs[j]] {
+				return s.verbRuns[verbs[i]] > s.verbRuns[verbs[j]]
+			}
+			return verbs[i] < verbs[j]
+		})
+		var topVerbs []string
+		for _, verb := range verbs[:min(len(verbs), maxReportedVerbs)] {
+			topVerbs = append(topVerbs, fmt.Sprintf("%s (%d)", verb, s.verbRuns[verb]))
+		}
+
+		buffer.WriteString(fmt.Sprintf("| %s | %d | %.1f | %.1f | %.1f | %.1f | %.1f | %.1f | %s |\n",
+			id, s.runs, float64(s.llmCalls)/n, float64(s.toolCalls)/n, float64(s.mutating)/n, float64(s.errors)/n,
+			s.modelSeconds/n, s.toolSeconds/n, strings.Join(topVerbs, ", ")))
+	}
+	buffer.WriteString("\n")
+}
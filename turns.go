@@ -0,0 +1,192 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// defaultTurnIdleTimeout is how long the agent must be quiet before we treat its turn as finished.
+const defaultTurnIdleTimeout = 10 * time.Second
+
+// turnPollInterval is how often we check whether the agent has gone idle.
+const turnPollInterval = 250 * time.Millisecond
+
+// startupQuietPeriod is how long the agent must be quiet after it starts before the first step is
+// sent, so that a startup banner is not taken as the response to that step.
+const startupQuietPeriod = time.Second
+
+// agentTurn is the output the agent produced in response to one or more script steps.
+type agentTurn struct {
+	// Steps holds the indexes of the script steps sent during this turn.
+	// In interactive mode this is a single step.
+	Steps  []int
+	Prompt string
+	Output string
+}
+
+// turnRecorder captures agent output for the current turn and tracks when the agent was last active.
+type turnRecorder struct {
+	mu           sync.Mutex
+	buf          bytes.Buffer
+	lastActivity time.Time
+
+	// sent is the length of buf when the last message was sent to the agent; only the output
+	// after it is a response to that message.
+	sent int
+}
+
+func (r *turnRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastActivity = time.Now()
+	return r.buf.Write(p)
+}
+
+// touch records agent activity that did not produce output (e.g. trace events).
+func (r *turnRecorder) touch() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastActivity = time.Now()
+}
+
+// sending marks that a message is about to be sent to the agent, so that output written before,
+// such as a startup banner or the input prompt, is not taken as its response.
+func (r *turnRecorder) sending() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = r.buf.Len()
+}
+
+// take returns the output captured since the last call and starts a new turn.
+func (r *turnRecorder) take() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.buf.String()
+	r.buf.Reset()
+	r.sent = 0
+	return s
+}
+
+// snapshot returns the output written since the last message was sent and when the agent was last active.
+func (r *turnRecorder) snapshot() (string, time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buf.String()[r.sent:], r.lastActivity
+}
+
+// turnWaiter decides when the agent has finished its turn.
+type turnWaiter struct {
+	recorder *turnRecorder
+
+	// idleTimeout is how long output must be quiescent (after the agent has responded to the last message).
+	idleTimeout time.Duration
+
+	// promptMarker, if set, ends the turn as soon as it matches the turn output.
+	promptMarker *regexp.Regexp
+
+	// tracePath is watched for growth; new trace events count as agent activity.
+	tracePath string
+
+	// turnDone, if set, reports exactly when the agent has finished its turn, for agents that know.
+	turnDone func() <-chan struct{}
+}
+
+// waitStarted blocks until the agent is ready for its first message: its output matches the
+// prompt marker, or it has been quiet for startupQuietPeriod, or idleTimeout has passed.
+func (w *turnWaiter) waitStarted(ctx context.Context, exited <-chan struct{}) {
+	if w.turnDone != nil {
+		// The agent tells when it responds, so there is no banner to mistake for a response.
+		return
+	}
+
+	ticker := time.NewTicker(turnPollInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		output, lastActivity := w.recorder.snapshot()
+		if w.promptMarker != nil && w.promptMarker.MatchString(output) {
+			return
+		}
+		if lastActivity.IsZero() {
+			lastActivity = start
+		}
+		if time.Since(lastActivity) >= startupQuietPeriod || time.Since(start) >= w.idleTimeout {
+			return
+		}
+	}
+}
+
+// wait blocks until the agent has finished its turn (or gone idle), the agent has exited, or ctx is done.
+func (w *turnWaiter) wait(ctx context.Context, exited <-chan struct{}) {
+	if w.turnDone != nil {
+		select {
+		case <-ctx.Done():
+		case <-exited:
+		case <-w.turnDone():
+		}
+		return
+	}
+
+	ticker := time.NewTicker(turnPollInterval)
+	defer ticker.Stop()
+
+	var traceSize int64 = -1
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-exited:
+			return
+		case <-ticker.C:
+		}
+
+		if w.tracePath != "" {
+			if info, err := os.Stat(w.tracePath); err == nil {
+				if traceSize >= 0 && info.Size() != traceSize {
+					w.recorder.touch()
+				}
+				traceSize = info.Size()
+			}
+		}
+
+		output, lastActivity := w.recorder.snapshot()
+		if output == "" {
+			// The agent has not responded yet; it may still be waiting on the model.
+			// Activity before its response (e.g. trace events) does not start the idle timer.
+			continue
+		}
+		if w.promptMarker != nil && w.promptMarker.MatchString(output) {
+			return
+		}
+		if time.Since(lastActivity) >= w.idleTimeout {
+			return
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// allowedDifficulties are the valid values of Task.Difficulty.
+var allowedDifficulties = []string{"easy", "medium", "hard"}
+
+// labelRegex is the allowed format of Task.Category and Task.Tags.
+var labelRegex = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+type ValidateConfig struct {
+	TasksDir     string
+	TaskPattern  string
+	OutputFormat string
+}
+
+// TaskValidation is the validation outcome for a single task directory.
+type TaskValidation struct {
+	Task   string   `json:"task"`
+	Valid  bool     `json:"valid"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ValidationReport is the machine-readable output of the validate subcommand.
+type ValidationReport struct {
+	TasksDir string           `json:"tasksDir"`
+	Valid    bool             `json:"valid"`
+	Tasks    []TaskValidation `json:"tasks"`
+}
+
+func runValidate() error {
+	config := ValidateConfig{
+		TasksDir:     "./tasks",
+		OutputFormat: "json",
+	}
+
+	// Set custom usage for 'validate' subcommand
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s validate [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Lint k8s-ai-bench task directories.\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.StringVar(&config.TasksDir, "tasks-dir", config.TasksDir, "Directory containing evaluation tasks")
+	flag.StringVar(&config.TaskPattern, "task-pattern", config.TaskPattern, "Pattern to filter tasks (e.g. 'pod' or 'redis')")
+	flag.StringVar(&config.OutputFormat, "output-format", config.OutputFormat, "Output format (json or text)")
+	flag.Parse()
+
+	if config.OutputFormat != "json" && config.OutputFormat != "text" {
+		return fmt.Errorf("invalid output format: %s, valid options are 'json' or 'text'", config.OutputFormat)
+	}
+
+	report, err := validateTasks(config)
+	if err != nil {
+		return err
+	}
+
+	switch config.OutputFormat {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling report to JSON: %w", err)
+		}
+		fmt.Println(string(data))
+	case "text":
+		for _, task := range report.Tasks {
+			if task.Valid {
+				fmt.Printf("ok    %s\n", task.Task)
+				continue
+			}
+			fmt.Printf("FAIL  %s\n", task.Task)
+			for _, e := range task.Errors {
+				fmt.Printf("      %s\n", e)
+			}
+		}
+	}
+
+	if !report.Valid {
+		invalid := 0
+		for _, task := range report.Tasks {
+			if !task.Valid {
+				invalid++
+			}
+		}
+		return fmt.Errorf("%d of %d tasks failed validation", invalid, len(report.Tasks))
+	}
+	return nil
+}
+
+// validateTasks lints every task directory under config.TasksDir, including disabled tasks.
+func validateTasks(config ValidateConfig) (*ValidationReport, error) {
+	var taskFilter *regexp.Regexp
+	if config.TaskPattern != "" {
+		var err error
+		taskFilter, err = regexp.Compile(config.TaskPattern)
+		if err != nil {
+			return nil, fmt.Errorf("compiling task pattern regex %q: %w", config.TaskPattern, err)
+		}
+	}
+
+	entries, err := os.ReadDir(config.TasksDir)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ValidationReport{TasksDir: config.TasksDir, Valid: true}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		taskID := entry.Name()
+		if taskFilter != nil && !taskFilter.MatchString(taskID) {
+			continue
+		}
+		if !isTaskDir(filepath.Join(config.TasksDir, taskID)) {
+			continue
+		}
+
+		errs := validateTask(filepath.Join(config.TasksDir, taskID))
+		validation := TaskValidation{Task: taskID, Valid: len(errs) == 0}
+		for _, err := range errs {
+			validation.Errors = append(validation.Errors, err.Error())
+		}
+		if !validation.Valid {
+			report.Valid = false
+		}
+		report.Tasks = append(report.Tasks, validation)
+	}
+	return report, nil
+}
+
+// validateTask checks a single task directory, returning every problem found.
+func validateTask(taskDir string) []error {
+	var errs []error
+	addErr := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	taskFile := filepath.Join(taskDir, "task.yaml")
+	data, err := os.ReadFile(taskFile)
+	if err != nil {
+		addErr("reading task file: %v", err)
+		return errs
+	}
+
+	var task Task
+	if err := yaml.UnmarshalStrict(data, &task); err != nil {
+		addErr("parsing task file: %v", err)
+		return errs
+	}
+
+	if !slices.Contains(allowedDifficulties, task.Difficulty) {
+		addErr("difficulty %q is not one of %s", task.Difficulty, strings.Join(allowedDifficulties, ", "))
+	}
+	if task.Category != "" && !labelRegex.MatchString(task.Category) {
+		addErr("category %q must be lowercase alphanumeric words separated by '-'", task.Category)
+	}
+	for i, tag := range task.Tags {
+		if !labelRegex.MatchString(tag) {
+			addErr("tags[%d]: %q must be lowercase alphanumeric words separated by '-'", i, tag)
+		}
+	}
+	if task.Isolation != "" && task.Isolation != IsolationModeCluster && task.Isolation != IsolationModeNamespace {
+		addErr("unknown isolation mode %q", task.Isolation)
+	}
+
+	for _, d := range []struct{ field, value string }{
+		{"timeout", task.Timeout},
+		{"turnIdleTimeout", task.TurnIdleTimeout},
+	} {
+		if d.value == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d.value); err != nil {
+			addErr("%s: %v", d.field, err)
+		}
+	}
+	if task.PromptMarker != "" {
+		if _, err := regexp.Compile(task.PromptMarker); err != nil {
+			addErr("promptMarker: %v", err)
+		}
+	}
+
+	for _, script := range []struct{ field, path string }{
+		{"setup", task.Setup},
+		{"verifier", task.Verifier},
+		{"cleanup", task.Cleanup},
+		{"solution", task.Solution},
+	} {
+		if err := validateScript(taskDir, script.path); err != nil {
+			addErr("%s: %v", script.field, err)
+		}
+	}
+	if task.VerifierWeight != nil && *task.VerifierWeight < 0 {
+		addErr("verifierWeight must not be negative")
+	}
+
+	for i, expect := range task.Expect {
+		for _, err := range validateExpectation(expect) {
+			addErr("expect[%d]: %v", i, err)
+		}
+	}
+
+	if task.Requires != nil {
+		if err := task.Requires.Validate(); err != nil {
+			addErr("requires: %v", err)
+		}
+	}
+
+	if task.User != nil {
+		if err := task.User.Validate(); err != nil {
+			addErr("user: %v", err)
+		}
+	}
+
+	for i := range task.Ready {
+		if err := task.Ready[i].Validate(); err != nil {
+			addErr("ready[%d]: %v", i, err)
+		}
+	}
+
+	for i := range task.Verify {
+		if err := task.Verify[i].Validate(); err != nil {
+			addErr("verify[%d]: %v", i, err)
+		}
+	}
+
+	if len(task.Script) == 0 {
+		addErr("script must contain at least one step")
+	}
+	hasStepChecks := false
+	for i, step := range task.Script {
+		if _, err := step.ResolvePrompt(taskDir); err != nil {
+			addErr("script[%d]: %v", i, err)
+		}
+		for j, expect := range step.Expect {
+			for _, err := range validateExpectation(expect) {
+				addErr("script[%d].expect[%d]: %v", i, j, err)
+			}
+		}
+		if err := validateScript(taskDir, step.Verifier); err != nil {
+			addErr("script[%d].verifier: %v", i, err)
+		}
+		if step.VerifierWeight != nil && *step.VerifierWeight < 0 {
+			addErr("script[%d].verifierWeight must not be negative", i)
+		}
+		if len(step.Expect) > 0 || step.Verifier != "" {
+			hasStepChecks = true
+		}
+	}
+
+	variantIDs := map[string]bool{}
+	for i, variant := range task.Variants {
+		if err := variant.Validate(task.Script); err != nil {
+			addErr("variants[%d]: %v", i, err)
+			continue
+		}
+		if variantIDs[variant.ID] {
+			addErr("variants[%d]: duplicate id %q", i, variant.ID)
+		}
+		variantIDs[variant.ID] = true
+		for j, step := range variant.Script {
+			if _, err := step.ResolvePrompt(taskDir); err != nil {
+				addErr("variants[%d].script[%d]: %v", i, j, err)
+			}
+		}
+	}
+
+	builtins := map[string]string{}
+	if task.Isolation == IsolationModeNamespace {
+		builtins[taskNamespaceVariable] = "validate"
+	}
+	if len(task.Variables) > 0 || len(builtins) > 0 {
+		// Render a sample instance of every variant so template errors (e.g. undeclared variables) surface here.
+		for _, id := range task.VariantIDs() {
+			variant, err := task.WithVariant(id)
+			if err != nil {
+				continue // reported above
+			}
+			if _, _, err := variant.Instantiate(taskDir, 1, builtins); err != nil {
+				if id == defaultVariant {
+					addErr("variables: %v", err)
+				} else {
+					addErr("variants[%s]: variables: %v", id, err)
+				}
+			}
+		}
+	}
+
+	if task.Verifier == "" && len(task.Verify) == 0 && len(task.Expect) == 0 && !hasStepChecks {
+		addErr("task has no verifier, verify assertions or expectations, so it can never succeed")
+	}
+
+	return errs
+}
+
+// validateExpectation checks that an expectation is well formed and its regexes compile.
+func validateExpectation(expect Expectation) []error {
+	var errs []error
+	if expect.Contains == "" && expect.NotContains == "" && expect.Set == nil && expect.Judge == nil {
+		errs = append(errs, fmt.Errorf("one of contains, notContains, set or judge must be specified"))
+	}
+	for _, re := range []struct{ field, pattern string }{
+		{"contains", expect.Contains},
+		{"notContains", expect.NotContains},
+	} {
+		if re.pattern == "" {
+			continue
+		}
+		if _, err := regexp.Compile(re.pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", re.field, err))
+		}
+	}
+	if expect.Set != nil {
+		if _, err := regexp.Compile(expect.Set.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("set.pattern: %w", err))
+		}
+		if expect.Set.Pattern == "" {
+			errs = append(errs, fmt.Errorf("set.pattern is required"))
+		}
+	}
+	if expect.Judge != nil {
+		if strings.TrimSpace(expect.Judge.Rubric) == "" {
+			errs = append(errs, fmt.Errorf("judge.rubric is required"))
+		}
+		if expect.Judge.PassScore < 0 || expect.Judge.PassScore > 1 {
+			errs = append(errs, fmt.Errorf("judge.passScore must be between 0 and 1"))
+		}
+	}
+	if expect.Weight != nil && *expect.Weight < 0 {
+		errs = append(errs, fmt.Errorf("weight must not be negative"))
+	}
+	return errs
+}
+
+// validateScript checks that a script referenced by a task exists and is executable.
+// An empty path is valid (the script is optional).
+func validateScript(taskDir, script string) error {
+	if script == "" {
+		return nil
+	}
+	info, err := os.Stat(filepath.Join(taskDir, script))
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", script)
+	}
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", script)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2025 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// defaultVariant is the ID of the prompt variant formed by the task's own script.
+const defaultVariant = "default"
+
+// Values of --prompt-variants besides a number of variants to sample.
+const (
+	PromptVariantsAll     = "all"
+	PromptVariantsDefault = "default"
+)
+
+// PromptVariant is a paraphrase of the task's script. It replaces the prompt of each script step;
+// expectations and verifiers are shared with the task script, so every variant is graded the same way.
+type PromptVariant struct {
+	// ID identifies the variant in results, e.g. "terse" or "non-expert".
+	ID string `json:"id"`
+
+	// Script holds the variant's prompt (or promptFile) for each step of the task script, in order.
+	Script []ScriptStep `json:"script"`
+}
+
+// VariantIDs returns the IDs of the prompt variants of the task, starting with the default variant.
+func (t *Task) VariantIDs() []string {
+	ids := []string{defaultVariant}
+	for _, v := range t.Variants {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+// Validate checks that the variant is a paraphrase of script: one prompt per step and nothing else.
+func (v *PromptVariant) Validate(script []ScriptStep) error {
+	if !labelRegex.MatchString(v.ID) {
+		return fmt.Errorf("id %q must be lowercase alphanumeric with dashes", v.ID)
+	}
+	if v.ID == defaultVariant {
+		return fmt.Errorf("id %q is reserved for the task script", defaultVariant)
+	}
+	if len(v.Script) != len(script) {
+		return fmt.Errorf("has %d script steps, the task script has %d", len(v.Script), len(script))
+	}
+	for i, step := range v.Script {
+		if len(step.Expect) > 0 || step.Verifier != "" || step.VerifierWeight != nil {
+			return fmt.Errorf("script[%d]: variants may only set prompt or promptFile", i)
+		}
+	}
+	return nil
+}
+
+// WithVariant returns a copy of the task whose script prompts are those of the given variant.
+func (t *Task) WithVariant(id string) (Task, error) {
+	instance := *t
+	instance.variant = id
+	if id == defaultVariant {
+		return instance, nil
+	}
+	for _, v := range t.Variants {
+		if v.ID != id {
+			continue
+		}
+		if err := v.Validate(t.Script); err != nil {
+			return Task{}, fmt.Errorf("variant %q: %w", id, err)
+		}
+		instance.Script = make([]ScriptStep, len(t.Script))
+		for i, step := range t.Script {
+			step.Prompt = v.Script[i].Prompt
+			step.PromptFile = v.Script[i].PromptFile
+			instance.Script[i] = step
+		}
+		return instance, nil
+	}
+	return Task{}, fmt.Errorf("task has no prompt variant %q", id)
+}
+
+// selectVariants returns the IDs of the prompt variants to run for the task, according to --prompt-variants:
+// all of them, only the default, or a number of them sampled reproducibly from the run seed.
+func (t *Task) selectVariants(selection string, seed int64, taskID string) ([]string, error) {
+	ids := t.VariantIDs()
+	switch selection {
+	case "", PromptVariantsDefault:
+		return ids[:1], nil
+	case PromptVariantsAll:
+		return ids, nil
+	}
+	n, err := strconv.Atoi(selection)
+	if err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid --prompt-variants %q, expected %q, %q or a positive number", selection, PromptVariantsAll, PromptVariantsDefault)
+	}
+	if n >= len(ids) {
+		return ids, nil
+	}
+	r := rand.New(rand.NewSource(instanceSeed(seed, taskID+"/variants")))
+	r.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+	return ids[:n], nil
+}